@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/errs"
+	"github.com/dele454/medium/csv-transform-to-html/internal/parser"
+	"github.com/dele454/medium/csv-transform-to-html/internal/transform"
+)
+
+// sourceExts extensions of the files picked up from a directory,
+// once stripped of any compression extension
+var sourceExts = []string{".csv", ".tsv", ".xlsx", ".xlsm"}
+
+// resolveFiles expands the given paths into the source files to
+// transform. A path is either a file, stdin, a glob pattern or a
+// directory, in which case the csv files and workbooks directly
+// within it are picked up.
+//
+// Reports if the paths make up a batch run, i.e more than one path,
+// a glob or a directory was given. Paths resolving to no file at all,
+// or to files whose outputs would overwrite one another or the index
+// page of a batch run, are refused.
+func resolveFiles(paths []string) ([]string, bool, error) {
+	if len(paths) == 0 {
+		return nil, false, errs.ErrorNoSourceFiles
+	}
+
+	var (
+		files []string
+		batch = len(paths) > 1
+		seen  = make(map[string]string)
+	)
+
+	add := func(file string) error {
+		name := parser.SourceName(file)
+		if prev, ok := seen[name]; ok {
+			if prev == filepath.Clean(file) {
+				return nil
+			}
+			return fmt.Errorf(errs.ErrorDuplicateOutput.Error(), prev, file, name)
+		}
+
+		seen[name] = filepath.Clean(file)
+		files = append(files, file)
+		return nil
+	}
+
+	for _, p := range paths {
+		if p == parser.Stdin {
+			if err := add(p); err != nil {
+				return nil, false, err
+			}
+			continue
+		}
+
+		matches, expanded, err := expand(p)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if len(matches) == 0 {
+			return nil, false, fmt.Errorf(errs.ErrorNoFilesMatched.Error(), p)
+		}
+
+		batch = batch || expanded
+		for _, m := range matches {
+			if err := add(m); err != nil {
+				return nil, false, err
+			}
+		}
+	}
+
+	// the index page of a batch run is written to the output folder too
+	if file, ok := seen[transform.BatchIndexName]; ok && batch {
+		return nil, false, fmt.Errorf(errs.ErrorReservedOutput.Error(), file, transform.BatchIndexName)
+	}
+
+	return files, batch, nil
+}
+
+// expand lists the files of a path, reporting if the path was
+// a glob pattern or a directory.
+func expand(p string) ([]string, bool, error) {
+	// glob pattern
+	if strings.ContainsAny(p, "*?[") {
+		matches, err := filepath.Glob(p)
+		if err != nil {
+			return nil, true, err
+		}
+
+		var files []string
+		for _, m := range matches {
+			if f, err := os.Stat(m); err == nil && !f.IsDir() {
+				files = append(files, m)
+			}
+		}
+
+		return files, true, nil
+	}
+
+	f, err := os.Stat(p)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !f.IsDir() {
+		return []string{p}, false, nil
+	}
+
+	// csv files directly within the directory
+	entries, err := os.ReadDir(p)
+	if err != nil {
+		return nil, true, err
+	}
+
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && isSourceFile(e.Name()) {
+			files = append(files, filepath.Join(p, e.Name()))
+		}
+	}
+
+	sort.Strings(files)
+	return files, true, nil
+}
+
+// isSourceFile reports if the file looks like a csv file, compressed
+// or not, or a workbook
+func isSourceFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(parser.SourceName(name)))
+	for _, e := range sourceExts {
+		if ext == e {
+			return true
+		}
+	}
+
+	return false
+}
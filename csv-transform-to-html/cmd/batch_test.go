@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
+)
+
+// outputDir temporary folder the outputs of the tests are written to
+var outputDir string
+
+// TestMain writes the outputs of the tests to a temporary folder
+func TestMain(m *testing.M) {
+	var err error
+	if outputDir, err = os.MkdirTemp("", "output"); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+
+	os.RemoveAll(outputDir)
+	os.Exit(code)
+}
+
+// batchDir creates a dir holding copies of the testdata files
+// along with a file which is not a csv file.
+func batchDir(t *testing.T) string {
+	dir := t.TempDir()
+
+	for _, name := range []string{"100_sales_records.csv", "reordered_sales_records.csv", "100_sales_records.csv.bz2"} {
+		b, err := os.ReadFile(utils.RootDir() + "/internal/testdata/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the bz2 copy is renamed so its output doesn't clash
+		if strings.HasSuffix(name, ".bz2") {
+			name = "compressed_sales_records.csv.bz2"
+		}
+
+		if err = os.WriteFile(filepath.Join(dir, name), b, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+func TestResolveFiles(t *testing.T) {
+	dir := batchDir(t)
+
+	tests := []struct {
+		name     string
+		paths    []string
+		expected int
+		batch    bool
+	}{
+		{"single file", []string{filepath.Join(dir, "100_sales_records.csv")}, 1, false},
+		{"stdin", []string{"-"}, 1, false},
+		{"several files", []string{filepath.Join(dir, "100_sales_records.csv"), filepath.Join(dir, "reordered_sales_records.csv")}, 2, true},
+		{"directory", []string{dir}, 3, true},
+		{"glob", []string{filepath.Join(dir, "*.csv")}, 2, true},
+		{"same file twice", []string{dir, filepath.Join(dir, "100_sales_records.csv")}, 3, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, batch, err := resolveFiles(tt.paths)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(files) != tt.expected || batch != tt.batch {
+				t.Fatalf("\nFiles Mismatch:\nExpected: %v files, batch %v\nGot: %v, batch %v", tt.expected, tt.batch, files, batch)
+			}
+		})
+	}
+
+	// no matches
+	if _, _, err := resolveFiles([]string{filepath.Join(dir, "*.xml")}); err == nil {
+		t.Fatal("Expected an error for a glob without matches")
+	}
+
+	// files sharing a name would overwrite each other's output
+	other := t.TempDir()
+	if err := os.WriteFile(filepath.Join(other, "100_sales_records.csv"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := resolveFiles([]string{dir, other}); err == nil {
+		t.Fatal("Expected an error for files sharing an output")
+	}
+
+	// a file named index would overwrite the index page of the batch
+	index := filepath.Join(other, "index")
+	if err := os.WriteFile(index, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := resolveFiles([]string{dir, index}); err == nil {
+		t.Fatal("Expected an error for a file sharing the output of the index page")
+	}
+
+	if _, _, err := resolveFiles([]string{index}); err != nil {
+		t.Fatalf("Expected a single file named index to be transformed, got %v", err)
+	}
+
+	// nothing to transform
+	if _, _, err := resolveFiles(nil); err == nil {
+		t.Fatal("Expected an error for no source files")
+	}
+}
+
+func TestProcessBatch(t *testing.T) {
+	dir := batchDir(t)
+
+	err := Process(Config{OutputDir: outputDir, Files: []string{dir}, Format: "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(utils.OutputDir() + "/index.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, link := range []string{"100_sales_records.csv.json", "reordered_sales_records.csv.json", "compressed_sales_records.csv.json"} {
+		if !strings.Contains(string(b), `href="`+link+`"`) {
+			t.Fatalf("Index should link to %s", link)
+		}
+
+		if _, err := os.Stat(utils.OutputDir() + "/" + link); err != nil {
+			t.Fatalf("Output %s should be written: %v", link, err)
+		}
+	}
+
+	// 100 + 10 + 100 records
+	if !strings.Contains(string(b), "<tr><th>Total Transformed Records</th><td>210</td></tr>") {
+		t.Fatal("Index should hold the roll-up of all files")
+	}
+}
+
+func TestProcessBatchFailedFile(t *testing.T) {
+	dir := t.TempDir()
+
+	b, err := os.ReadFile(utils.RootDir() + "/internal/testdata/100_sales_records.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = os.WriteFile(filepath.Join(dir, "100_sales_records.csv"), b, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// a corrupt file fails on its own without stopping the run
+	if err = os.WriteFile(filepath.Join(dir, "corrupt_sales_records.csv.gz"), []byte("not gzipped"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err = Process(Config{OutputDir: outputDir, Files: []string{dir}, Format: "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err = os.ReadFile(utils.OutputDir() + "/index.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, row := range []string{
+		"<tr><th>Total Files</th><td>2</td></tr>",
+		"<tr><th>Total Failed Files</th><td>1</td></tr>",
+		"<tr><th>Total Transformed Records</th><td>100</td></tr>",
+		`<tr class="danger">`,
+	} {
+		if !strings.Contains(string(b), row) {
+			t.Fatalf("Index should hold %s", row)
+		}
+	}
+
+	if _, err := os.Stat(utils.OutputDir() + "/100_sales_records.csv.json"); err != nil {
+		t.Fatalf("Output of the good file should be written: %v", err)
+	}
+}
@@ -1,35 +1,199 @@
 package cmd
 
 import (
+	"context"
 	"sync"
 
+	"github.com/dele454/medium/csv-transform-to-html/internal/checkpoint"
 	"github.com/dele454/medium/csv-transform-to-html/internal/parser"
 	"github.com/dele454/medium/csv-transform-to-html/internal/report"
+	"github.com/dele454/medium/csv-transform-to-html/internal/rules"
+	"github.com/dele454/medium/csv-transform-to-html/internal/templates"
 	"github.com/dele454/medium/csv-transform-to-html/internal/transform"
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
 )
 
-func Process(file string) {
+// Config options for a transformation run
+type Config struct {
+	// Files source files to transform, either full paths,
+	// glob patterns or directories
+	Files []string
+	// Format output format of the transformation
+	Format string
+	// TemplateDir directory holding custom output/report templates
+	TemplateDir string
+	// OutputDir directory the outputs are written to, output
+	// within the working directory if not set
+	OutputDir string
+	// PageSize nos of rows per page of a paginated html output
+	PageSize int
+	// Interactive writes a self-contained sortable & filterable html output
+	Interactive bool
+	// Summary adds an aggregated summary of the sales to the html output
+	Summary bool
+	// Charts adds inline svg charts above the html table
+	Charts bool
+	// Dialect flavour of the source csv file
+	Dialect parser.Dialect
+	// Sheet sheet of a source workbook to read, the first sheet if not set
+	Sheet string
+	// CheckpointEvery nos of rows between checkpoints of the
+	// transformation of a csv file, zero disables checkpoints
+	CheckpointEvery int
+	// Resume resumes the interrupted transformation of a csv
+	// file from its checkpoint, if any
+	Resume bool
+	// Tolerances largest differences allowed between the totals of
+	// a record and the totals worked out from its other fields,
+	// zero tolerances requiring the totals to match exactly
+	Tolerances rules.Tolerances
+}
+
+// Process transforms every source file of the run into its own
+// output. A batch run also writes a combined index page and a
+// roll-up report of the stats of all files.
+func Process(cfg Config) error {
+	// look up custom templates, if any
+	if err := templates.SetDir(cfg.TemplateDir); err != nil {
+		return err
+	}
+
+	// write the outputs where asked
+	utils.SetOutputDir(cfg.OutputDir)
+
+	// the totals of the records are checked with the tolerances
+	if err := cfg.Tolerances.Validate(); err != nil {
+		return err
+	}
+
+	// a bad processor tag would reject every row
+	if err := utils.CheckTags[utils.SalesRecord](); err != nil {
+		return err
+	}
+
+	// expand globs & directories
+	files, batch, err := resolveFiles(cfg.Files)
+	if err != nil {
+		return err
+	}
+
+	rollup := report.NewRollUp()
+	for _, file := range files {
+		reporter, err := processFile(file, cfg)
+		if err != nil {
+			return err
+		}
+
+		rollup.Add(reporter)
+	}
+
+	if !batch {
+		return nil
+	}
+
+	rollup.Completed()
+
+	if err := transform.WriteBatchIndex(cfg.Format, rollup); err != nil {
+		return err
+	}
+
+	return rollup.WriteReportToStdOut(context.Background())
+}
+
+// processFile transforms a single source file, returning its report
+func processFile(file string, cfg Config) (report.Reporter, error) {
 	// create a reporter
 	reporter := report.NewTransformationReporter()
 
+	opts := transform.Options{
+		PageSize:        cfg.PageSize,
+		Interactive:     cfg.Interactive,
+		Summary:         cfg.Summary,
+		Charts:          cfg.Charts,
+		CheckpointEvery: cfg.CheckpointEvery,
+		Tolerances:      cfg.Tolerances,
+	}
+
+	// pick up where an interrupted transformation left off
+	resume, err := checkpoints(file, cfg, &opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if resume != nil {
+		reporter.Restore(resume.Processed, resume.Transformed, resume.Failed)
+	}
+
+	// create a transformer for the requested format
+	transformer, err := transform.NewTransformer(cfg.Format, reporter, opts)
+	if err != nil {
+		return nil, err
+	}
+
 	// create a new parser
-	parser := parser.NewCSVParser(file, reporter)
+	parser := parser.NewParser(file, reporter, parser.Options{
+		Dialect: cfg.Dialect,
+		Sheet:   cfg.Sheet,
+		Resume:  resume,
+	})
 
 	// create waitgroup
 	wg := new(sync.WaitGroup)
 	wg.Add(2)
 
 	// channels for pipeline
-	record := make(chan []string)
+	record := make(chan utils.Row)
 	done := make(chan bool)
 
 	// transformer
-	go transform.NewHTMLTransformer(reporter).
-		ProcessRecord(wg, record, done)
+	go transformer.ProcessRecord(wg, record, done)
 
 	// read the csv
 	go parser.Read(wg, record, done)
 
 	// wait for all go routines to finish
 	wg.Wait()
+
+	return reporter, nil
+}
+
+// checkpoints sets up the checkpoints of the transformation of a
+// source file, returning the checkpoint to resume from, if any.
+//
+// Transformations which cannot be resumed aren't checkpointed, and
+// fail to start if asked to resume.
+func checkpoints(file string, cfg Config, opts *transform.Options) (*checkpoint.Checkpoint, error) {
+	if cfg.CheckpointEvery <= 0 && !cfg.Resume {
+		return nil, nil
+	}
+
+	name := parser.SourceName(file)
+
+	err := transform.Resumable(name, cfg.Format, *opts)
+	if err == nil {
+		err = parser.Resumable(file, cfg.Dialect)
+	}
+
+	switch {
+	case err != nil && cfg.Resume:
+		return nil, err
+	case err != nil:
+		return nil, nil
+	}
+
+	var resume *checkpoint.Checkpoint
+	if cfg.Resume {
+		if resume, err = checkpoint.Load(name, file); err != nil {
+			return nil, err
+		}
+	}
+
+	opts.Checkpoint = resume
+	if resume == nil {
+		if opts.Checkpoint, err = checkpoint.New(file); err != nil {
+			return nil, err
+		}
+	}
+
+	return resume, nil
 }
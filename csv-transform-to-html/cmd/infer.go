@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"io"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/infer"
+	"github.com/dele454/medium/csv-transform-to-html/internal/parser"
+)
+
+// InferConfig options for inferring the schema of a csv file
+type InferConfig struct {
+	// File full path to the source file, or - for stdin
+	File string
+	// Name name of the struct emitted for the schema
+	Name string
+	// Sample nos of rows sampled
+	Sample int
+	// Dialect flavour of the source csv file
+	Dialect parser.Dialect
+}
+
+// Infer samples a csv file and writes a Go struct with csv and
+// processor tags for its schema.
+func Infer(cfg InferConfig, w io.Writer) error {
+	reader, f, err := parser.OpenCSV(cfg.File, cfg.Dialect)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	schema, err := infer.Infer(reader, cfg.Name, parser.SourceName(cfg.File), cfg.Sample)
+	if err != nil {
+		return err
+	}
+
+	return schema.WriteStruct(w)
+}
@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/checkpoint"
+	"github.com/dele454/medium/csv-transform-to-html/internal/parser"
+	"github.com/dele454/medium/csv-transform-to-html/internal/report"
+	"github.com/dele454/medium/csv-transform-to-html/internal/transform"
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
+)
+
+// resumeSource creates a copy of the testdata file starting with
+// a BOM and holding a row of the wrong length before and after the
+// first checkpoint.
+func resumeSource(t *testing.T) string {
+	b, err := os.ReadFile(utils.RootDir() + "/internal/testdata/100_sales_records.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.SplitAfter(string(b), "\n")
+	lines = append(lines[:61], append([]string{"short,row\n"}, lines[61:]...)...)
+	lines = append(lines[:21], append([]string{"short,row\n"}, lines[21:]...)...)
+
+	src := filepath.Join(t.TempDir(), "resumed_sales_records.csv")
+	content := append([]byte{0xef, 0xbb, 0xbf}, strings.Join(lines, "")...)
+	if err = os.WriteFile(src, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return src
+}
+
+// interrupt transforms the first rows of the source file only, or
+// the rows following the checkpoint if resuming, returning the
+// checkpoint left behind
+func interrupt(t *testing.T, src string, cfg Config, rows int) []byte {
+	reporter := report.NewTransformationReporter()
+
+	opts := transform.Options{CheckpointEvery: cfg.CheckpointEvery}
+	resume, err := checkpoints(src, cfg, &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resume != nil {
+		reporter.Restore(resume.Processed, resume.Transformed, resume.Failed)
+	}
+
+	tr, err := transform.NewTransformer(cfg.Format, reporter, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := parser.NewParser(src, reporter, parser.Options{Resume: resume})
+
+	wg := new(sync.WaitGroup)
+	wg.Add(2)
+
+	read, readDone := make(chan utils.Row), make(chan bool)
+	record, done := make(chan utils.Row), make(chan bool)
+
+	go tr.ProcessRecord(wg, record, done)
+	go p.Read(wg, read, readDone)
+
+	for i := 0; i < rows; i++ {
+		record <- <-read
+	}
+
+	cp, err := os.ReadFile(checkpoint.Path(filepath.Base(src)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// leave the rest of the file unread
+	go func() {
+		for range read {
+		}
+	}()
+	<-readDone
+	done <- true
+
+	wg.Wait()
+
+	return cp
+}
+
+func TestResume(t *testing.T) {
+	src := resumeSource(t)
+	name := filepath.Base(src)
+	output := filepath.Join(utils.OutputDir(), name)
+
+	cfg := Config{OutputDir: outputDir, Format: transform.FormatHTML, CheckpointEvery: 40}
+
+	// transform the whole file in one go
+	if _, err := processFile(src, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(checkpoint.Path(name)); !os.IsNotExist(err) {
+		t.Fatalf("Expected checkpoint to be removed, got %v", err)
+	}
+
+	expected, err := os.ReadFile(output + ".html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedRejected, err := os.ReadFile(filepath.Join(utils.OutputDir(), "resumed_sales_records.csv.rejected.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// interrupt the transformation past the first checkpoint
+	cp := interrupt(t, src, cfg, 50)
+	if err = os.WriteFile(checkpoint.Path(name), cp, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.Resume = true
+	reporter, err := processFile(src, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(output + ".html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, expected) {
+		t.Fatalf("Expected resumed output to match the output of a single run")
+	}
+
+	rejected, err := os.ReadFile(filepath.Join(utils.OutputDir(), "resumed_sales_records.csv.rejected.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(rejected, expectedRejected) {
+		t.Fatalf("Expected %q, got %q", expectedRejected, rejected)
+	}
+
+	if reporter.GetTotalTransformedRecords() != 100 || reporter.GetTotalFailedRecords() != 2 {
+		t.Fatalf("Expected 100 transformed & 2 failed, got %d & %d",
+			reporter.GetTotalTransformedRecords(), reporter.GetTotalFailedRecords())
+	}
+
+	// errors found before the checkpoint aren't reported again
+	errors := reporter.GetErrors()
+	if len(errors) != 1 || !strings.Contains(errors[0].Error(), "Line 63") {
+		t.Fatalf("Expected the error on line 63 only, got %v", errors)
+	}
+
+	if _, err := os.Stat(checkpoint.Path(name)); !os.IsNotExist(err) {
+		t.Fatalf("Expected checkpoint to be removed, got %v", err)
+	}
+}
+
+func TestResumeTwice(t *testing.T) {
+	src := resumeSource(t)
+	name := filepath.Base(src)
+	output := filepath.Join(utils.OutputDir(), name)
+
+	cfg := Config{OutputDir: outputDir, Format: transform.FormatHTML, CheckpointEvery: 40}
+
+	// transform the whole file in one go
+	if _, err := processFile(src, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := os.ReadFile(output + ".html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// interrupt the transformation past the first checkpoint
+	cp := interrupt(t, src, cfg, 50)
+	if err = os.WriteFile(checkpoint.Path(name), cp, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// interrupt the resumed transformation past the next checkpoint,
+	// taken at an offset counted from the start of the file
+	cfg.Resume = true
+
+	cp = interrupt(t, src, cfg, 50)
+	if err = os.WriteFile(checkpoint.Path(name), cp, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := checkpoint.Load(name, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if state.Rows != 80 || state.Line != 81 {
+		t.Fatalf("Expected a checkpoint after 80 rows on line 81, got %d rows on line %d", state.Rows, state.Line)
+	}
+
+	reporter, err := processFile(src, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(output + ".html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, expected) {
+		t.Fatalf("Expected output resumed twice to match the output of a single run")
+	}
+
+	if reporter.GetTotalTransformedRecords() != 100 || reporter.GetTotalFailedRecords() != 2 {
+		t.Fatalf("Expected 100 transformed & 2 failed, got %d & %d",
+			reporter.GetTotalTransformedRecords(), reporter.GetTotalFailedRecords())
+	}
+}
+
+func TestResumeUnsupported(t *testing.T) {
+	path := utils.RootDir() + "/internal/testdata/"
+
+	tests := []struct {
+		file string
+		cfg  Config
+	}{
+		{file: path + "100_sales_records.csv.bz2", cfg: Config{OutputDir: outputDir, Format: transform.FormatHTML}},
+		{file: path + "sales_records.xlsx", cfg: Config{OutputDir: outputDir, Format: transform.FormatHTML}},
+		{file: path + "100_sales_records.csv", cfg: Config{OutputDir: outputDir, Format: transform.FormatJSON}},
+		{file: path + "100_sales_records.csv", cfg: Config{OutputDir: outputDir, Format: transform.FormatHTML, PageSize: 10}},
+		{file: path + "100_sales_records.csv", cfg: Config{OutputDir: outputDir, Format: transform.FormatHTML, Dialect: parser.Dialect{Encoding: parser.EncodingWindows1252}}},
+	}
+
+	for _, test := range tests {
+		test.cfg.Resume = true
+
+		opts := transform.Options{PageSize: test.cfg.PageSize}
+		if _, err := checkpoints(test.file, test.cfg, &opts); err == nil {
+			t.Fatalf("Expected %s to be refused, got nil", filepath.Base(test.file))
+		}
+
+		// such transformations simply aren't checkpointed
+		test.cfg.Resume = false
+		test.cfg.CheckpointEvery = 10
+
+		opts = transform.Options{PageSize: test.cfg.PageSize}
+		if _, err := checkpoints(test.file, test.cfg, &opts); err != nil || opts.Checkpoint != nil {
+			t.Fatalf("Expected %s not to be checkpointed, got %v", filepath.Base(test.file), err)
+		}
+	}
+}
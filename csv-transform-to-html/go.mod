@@ -1,5 +1,20 @@
 module github.com/dele454/medium/csv-transform-to-html
 
-go 1.18
+go 1.19
 
-require golang.org/x/text v0.3.7
+require (
+	github.com/klauspost/compress v1.15.9
+	github.com/shopspring/decimal v1.3.1
+	github.com/xuri/excelize/v2 v2.8.1
+	golang.org/x/text v0.14.0
+)
+
+require (
+	github.com/mohae/deepcopy v0.0.0-20170929034955-c48cc78d4826 // indirect
+	github.com/richardlehane/mscfb v1.0.4 // indirect
+	github.com/richardlehane/msoleps v1.0.3 // indirect
+	github.com/xuri/efp v0.0.0-20231025114914-d1ff6096ae53 // indirect
+	github.com/xuri/nfp v0.0.0-20230919160717-d98342af3f05 // indirect
+	golang.org/x/crypto v0.19.0 // indirect
+	golang.org/x/net v0.21.0 // indirect
+)
@@ -0,0 +1,122 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/errs"
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
+)
+
+// DefaultEvery nos of rows handled between checkpoints by default
+const DefaultEvery = 10000
+
+// Checkpoint progress of the transformation of a source file,
+// persisted periodically so a transformation interrupted part way
+// through can be resumed instead of started over.
+type Checkpoint struct {
+	// Source path of the source file, along with its size and
+	// modification time as a changed file cannot be resumed
+	Source  string
+	Size    int64
+	ModTime time.Time
+	// Offset byte offset of the decoded source file past the
+	// last row handled, where reading resumes from
+	Offset int64
+	// Line last line of the source file handled
+	Line int
+	// Rows nos of rows handled
+	Rows int
+	// counters of the reporter
+	Processed   int
+	Transformed int
+	Failed      int
+	// Output size of the output written so far
+	Output int64
+	// Rejected size of the rejected file written so far, if any
+	Rejected int64
+}
+
+// New creates an empty checkpoint for the source file
+func New(source string) (*Checkpoint, error) {
+	f, err := os.Stat(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Checkpoint{
+		Source:  source,
+		Size:    f.Size(),
+		ModTime: f.ModTime(),
+	}, nil
+}
+
+// Path path of the checkpoint of the named source file, kept
+// next to the output
+//
+// e.g sales.csv is checkpointed to output/sales.csv.checkpoint
+func Path(name string) string {
+	return filepath.Join(utils.OutputDir(), filepath.Base(name)+".checkpoint")
+}
+
+// Load loads the checkpoint of the named source file, if any
+//
+// A checkpoint taken before the source file changed is refused
+// as its offset no longer points past the rows handled.
+func Load(name, source string) (*Checkpoint, error) {
+	b, err := os.ReadFile(Path(name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cp Checkpoint
+	if err = json.Unmarshal(b, &cp); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Stat(source)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Size() != cp.Size || !f.ModTime().Equal(cp.ModTime) {
+		return nil, fmt.Errorf(errs.ErrorStaleCheckpoint.Error(), source)
+	}
+
+	return &cp, nil
+}
+
+// Save persists the checkpoint of the named source file
+//
+// The checkpoint is written to a temporary file first so an
+// interruption never leaves a partially written checkpoint.
+func (cp *Checkpoint) Save(name string) error {
+	b, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := Path(name)
+	if err = os.WriteFile(path+".tmp", b, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+// Remove removes the checkpoint of the named source file once
+// its transformation completes
+func Remove(name string) error {
+	err := os.Remove(Path(name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
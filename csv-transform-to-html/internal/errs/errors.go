@@ -5,6 +5,7 @@ import "errors"
 var (
 	ErrorUnmatachedHeaders       = errors.New("Expected headers don't match file's headers.")
 	ErrorHeaderNotFound          = errors.New("Header '%s' not found in source document.")
+	ErrorDuplicateHeader         = errors.New("Header '%s' found more than once in source document.")
 	ErrorNoHeadersFound          = errors.New("No headers found in source document.")
 	ErrorUnknownSourceFile       = errors.New("Unknown source file provided.")
 	ErrorNoSourceFileName        = errors.New("No source filename provided for reporting.")
@@ -12,6 +13,27 @@ var (
 	ErrorFailedToCreateDirectory = errors.New("Failed to create directory. %s")
 	ErrorFieldIsEmpty            = errors.New("'%s' Field cannot be empty.")
 	ErrorFieldNotValid           = errors.New("'%s' Field is not valid.")
+	ErrorFieldNotInEnum          = errors.New("'%s' Field must be one of %s.")
+	ErrorFieldBelowMin           = errors.New("'%s' Field must be at least %s.")
+	ErrorFieldAboveMax           = errors.New("'%s' Field must be at most %s.")
+	ErrorFieldLength             = errors.New("'%s' Field must be %d characters long.")
+	ErrorFieldTooLong            = errors.New("'%s' Field must be at most %d characters long.")
+	ErrorFieldPattern            = errors.New("'%s' Field must match %s.")
+	ErrorInvalidTag              = errors.New("Invalid processor tag '%s' on field '%s'.")
+	ErrorShipBeforeOrder         = errors.New("'ShipDate' Field must not be before the OrderDate %s.")
+	ErrorTotalMismatch           = errors.New("'%s' Field must equal %s, %s.")
+	ErrorInvalidTolerance        = errors.New("Invalid tolerance '%s', expected an amount of at least 0.")
 	ErrorCreditLimitInvalid      = errors.New("'%s' Field is invalid.")
-	ErrorArgsDirSpecified        = errors.New("A directory cannot be passed as an argument.")
+	ErrorUnsupportedFormat       = errors.New("Unsupported output format '%s'.")
+	ErrorTemplateDirNotDir       = errors.New("Templates path must be a directory.")
+	ErrorInvalidDialectRune      = errors.New("Invalid delimiter or comment character '%s'.")
+	ErrorInvalidFieldsPolicy     = errors.New("Invalid fields per record policy '%s', expected strict or ragged.")
+	ErrorNoFilesMatched          = errors.New("No source files match '%s'.")
+	ErrorDuplicateOutput         = errors.New("Source files '%s' and '%s' would both be written to output '%s'.")
+	ErrorReservedOutput          = errors.New("Source file '%s' would be written to output '%s', which holds the index of the batch.")
+	ErrorNoSourceFiles           = errors.New("No source files given.")
+	ErrorSheetNotFound           = errors.New("Sheet '%s' not found in source workbook.")
+	ErrorUnsupportedEncoding     = errors.New("Unsupported encoding '%s', expected utf-8, utf-16le, utf-16be, windows-1252 or iso-8859-1.")
+	ErrorResumeUnsupported       = errors.New("Cannot resume the transformation of '%s', %s.")
+	ErrorStaleCheckpoint         = errors.New("Source file '%s' changed since its checkpoint was taken.")
 )
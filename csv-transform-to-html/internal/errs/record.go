@@ -0,0 +1,65 @@
+package errs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError an error found in the value of a field of a record
+type FieldError struct {
+	// Index index of the field within the record
+	Index int
+	Field string
+	Value string
+	Err   error
+}
+
+// Error error message for FieldError type
+func (e *FieldError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// RecordError an error found in a row of the source file along
+// with its position, so the exact cell can be fixed.
+//
+// Field & Value are left empty for errors affecting the entire row.
+// For rows the csv parser fails to read, Column is the position
+// within the line the error was found at, 1 for rows of the wrong
+// length.
+type RecordError struct {
+	// Line line of the source file, starting at 1
+	Line int
+	// Column column of the source file, starting at 1, or 0 if unknown
+	Column int
+	Field  string
+	Value  string
+	Err    error
+}
+
+// Error error message for RecordError type
+//
+// e.g Line 12, Column 7, Field 'OrderID', Value '12x4': 'OrderID' Field is not valid.
+func (e *RecordError) Error() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Line %d", e.Line)
+	if e.Column > 0 {
+		fmt.Fprintf(&b, ", Column %d", e.Column)
+	}
+	if e.Field != "" {
+		fmt.Fprintf(&b, ", Field '%s', Value '%s'", e.Field, e.Value)
+	}
+	fmt.Fprintf(&b, ": %s", e.Err)
+
+	return b.String()
+}
+
+// Unwrap returns the underlying error
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,366 @@
+package infer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"go/format"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/errs"
+	"github.com/shopspring/decimal"
+)
+
+// Type type of the values of a column
+type Type string
+
+// Types a column can be inferred as, each mapping to a processor tag
+const (
+	TypeDate    Type = "date"
+	TypeInteger Type = "numeric"
+	TypeAmount  Type = "amount"
+	TypeText    Type = "text"
+)
+
+// DefaultSample nos of rows sampled by default
+const DefaultSample = 1000
+
+// maxEnum max nos of distinct values of a text column for its
+// values to be suggested as an enum
+const maxEnum = 12
+
+// layouts date layouts tried in order of preference, the first
+// one parsing every value of a column wins
+var layouts = []string{
+	"1/2/2006",
+	"2/1/2006",
+	"2006-01-02",
+	"2006/01/02",
+	"02.01.2006",
+	"2-Jan-2006",
+	"2-Jan-06",
+	"Jan 2, 2006",
+	"2006-01-02 15:04:05",
+	time.RFC3339,
+}
+
+// Schema schema inferred for a csv file
+type Schema struct {
+	// Name name of the struct emitted for the schema
+	Name string
+	// Source name of the file the schema was inferred from
+	Source string
+	// Rows nos of rows sampled
+	Rows    int
+	Columns []Column
+}
+
+// Column schema inferred for a column
+type Column struct {
+	Header string
+	// Field name of the struct field for the column
+	Field string
+	Type  Type
+	// Layout layout of the dates of a date column
+	Layout string
+	// Required no empty value was observed
+	Required bool
+	// Enum candidate values of a text column holding
+	// a handful of repeated values
+	Enum []string
+}
+
+// column values observed for a column
+type column struct {
+	values []string
+	empty  int
+}
+
+// Infer samples up to the given nos of rows of a csv file, after its
+// headers, and proposes a schema for its columns.
+func Infer(reader *csv.Reader, name, source string, sample int) (*Schema, error) {
+	if sample <= 0 {
+		sample = DefaultSample
+	}
+
+	// columns are named after the headers
+	reader.FieldsPerRecord = -1
+	headers, err := reader.Read()
+	if err == io.EOF {
+		return nil, errs.ErrorNoHeadersFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	observed := make([]column, len(headers))
+
+	var rows int
+	for rows < sample {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rows++
+		for i := range observed {
+			v := ""
+			if i < len(row) {
+				v = strings.TrimSpace(row[i])
+			}
+
+			if v == "" {
+				observed[i].empty++
+				continue
+			}
+			observed[i].values = append(observed[i].values, v)
+		}
+	}
+
+	schema := &Schema{Name: name, Source: source, Rows: rows}
+	fields := make(map[string]int)
+
+	for i, h := range headers {
+		c := inferColumn(observed[i], rows)
+		c.Header = strings.TrimSpace(h)
+		c.Field = fieldName(c.Header, i, fields)
+
+		schema.Columns = append(schema.Columns, c)
+	}
+
+	return schema, nil
+}
+
+// inferColumn infers the type of a column from its values
+func inferColumn(c column, rows int) Column {
+	col := Column{
+		Type:     TypeText,
+		Required: rows > 0 && c.empty == 0,
+	}
+
+	if len(c.values) == 0 {
+		return col
+	}
+
+	switch {
+	case all(c.values, isInteger):
+		col.Type = TypeInteger
+	case all(c.values, isAmount):
+		col.Type = TypeAmount
+	default:
+		if layout := dateLayout(c.values); layout != "" {
+			col.Type = TypeDate
+			col.Layout = layout
+			break
+		}
+
+		col.Enum = enum(c.values)
+	}
+
+	return col
+}
+
+// all reports if every value passes the check
+func all(values []string, check func(string) bool) bool {
+	for _, v := range values {
+		if !check(v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isInteger(v string) bool {
+	_, err := strconv.ParseInt(v, 10, 64)
+	return err == nil
+}
+
+// isAmount reports if the processor parses the value as an amount
+func isAmount(v string) bool {
+	_, err := decimal.NewFromString(v)
+	return err == nil
+}
+
+// dateLayout picks the first layout parsing every value, if any
+func dateLayout(values []string) string {
+	for _, layout := range layouts {
+		if all(values, func(v string) bool {
+			_, err := time.Parse(layout, v)
+			return err == nil
+		}) {
+			return layout
+		}
+	}
+
+	return ""
+}
+
+// enum lists the distinct values of a column if they are few and
+// repeated, a column of mostly unique values being free text.
+func enum(values []string) []string {
+	distinct := make(map[string]bool)
+	for _, v := range values {
+		distinct[v] = true
+		if len(distinct) > maxEnum {
+			return nil
+		}
+	}
+
+	if len(distinct)*2 > len(values) {
+		return nil
+	}
+
+	list := make([]string, 0, len(distinct))
+	for v := range distinct {
+		list = append(list, v)
+	}
+	sort.Strings(list)
+
+	return list
+}
+
+// fieldName makes an exported Go identifier out of a header
+//
+// e.g "order date" is named OrderDate. Names already taken are
+// suffixed with the column's position.
+func fieldName(header string, i int, taken map[string]int) string {
+	var b strings.Builder
+
+	upper := true
+	for _, r := range header {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+
+	name := b.String()
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		name = "Column" + name
+	}
+
+	if _, ok := taken[name]; ok {
+		name = fmt.Sprintf("%s%d", name, i+1)
+	}
+	taken[name] = i
+
+	return name
+}
+
+// Tags processor tags of a column
+func (c Column) Tags() []string {
+	var tags []string
+	switch {
+	case c.Type == TypeDate && !c.taggableLayout():
+		// noted as a comment instead
+	case c.Type == TypeDate && c.Layout != layouts[0]:
+		tags = append(tags, string(c.Type)+"="+c.Layout)
+	case c.Type != TypeText:
+		tags = append(tags, string(c.Type))
+	}
+
+	if c.Required {
+		tags = append(tags, "required")
+	}
+
+	if c.taggableEnum() {
+		tags = append(tags, "enum="+strings.Join(c.Enum, "|"))
+	}
+
+	return tags
+}
+
+// taggableEnum reports if the enum values of a column can be listed
+// within a tag, i.e none of them holds a separator of the tag
+func (c Column) taggableEnum() bool {
+	for _, v := range c.Enum {
+		if strings.ContainsAny(v, ",|\"`") {
+			return false
+		}
+	}
+
+	return len(c.Enum) > 0
+}
+
+// taggableLayout reports if the date layout of a column can be given
+// within a tag, i.e it holds no separator of the tag
+func (c Column) taggableLayout() bool {
+	return !strings.ContainsAny(c.Layout, ",\"`")
+}
+
+// GoType Go type of the struct field for a column, the type the
+// processor unmarshals the values of the column into
+//
+// Dates whose layout cannot be tagged are left as text.
+func (c Column) GoType() string {
+	switch {
+	case c.Type == TypeDate && c.taggableLayout():
+		return "time.Time"
+	case c.Type == TypeInteger:
+		return "int64"
+	case c.Type == TypeAmount:
+		return "decimal.Decimal"
+	default:
+		return "string"
+	}
+}
+
+// WriteStruct writes a Go struct for the schema with csv and processor
+// tags, ready to replace utils.SalesRecord.
+//
+// Date layouts other than 1/2/2006 are given to the date tag. Date
+// layouts and enum values which cannot be listed within a tag are
+// noted as comments of the fields instead.
+func (s *Schema) WriteStruct(w io.Writer) error {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "// %s inferred from %d rows of %s\n", s.Name, s.Rows, s.Source)
+	fmt.Fprintf(&b, "type %s struct {\n", s.Name)
+
+	for _, c := range s.Columns {
+		tag := fmt.Sprintf("csv:%q", c.Header)
+		if tags := c.Tags(); len(tags) > 0 {
+			tag += fmt.Sprintf(" processor:%q", strings.Join(tags, ","))
+		}
+
+		fmt.Fprintf(&b, "%s %s `%s`", c.Field, c.GoType(), tag)
+
+		var notes []string
+		if c.Type == TypeDate && !c.taggableLayout() {
+			notes = append(notes, "layout "+c.Layout)
+		}
+		if len(c.Enum) > 0 && !c.taggableEnum() {
+			notes = append(notes, "one of "+strings.Join(c.Enum, ", "))
+		}
+		if len(notes) > 0 {
+			fmt.Fprintf(&b, " // %s", strings.Join(notes, "; "))
+		}
+
+		b.WriteString("\n")
+	}
+
+	b.WriteString("}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(src)
+	return err
+}
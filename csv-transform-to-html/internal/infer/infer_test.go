@@ -0,0 +1,138 @@
+package infer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+const sample = `Order ID,order date,Ship Date,Amount,Channel,Notes,2nd Channel
+1,2021-03-01,01/03/2021,10.50,Online,first,Online
+2,2021-03-02,02/03/2021,7,Offline,,Offline
+3,2021-03-15,15/03/2021,3.25,Online,third,Online
+4,2021-04-01,01/04/2021,12,Online,fourth,Online
+`
+
+func TestInfer(t *testing.T) {
+	schema, err := Infer(csv.NewReader(strings.NewReader(sample)), "Feed", "feed.csv", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if schema.Rows != 4 {
+		t.Fatalf("\nRows Mismatch:\nExpected: %v\nGot: %v", 4, schema.Rows)
+	}
+
+	expected := []Column{
+		{Header: "Order ID", Field: "OrderID", Type: TypeInteger, Required: true},
+		{Header: "order date", Field: "OrderDate", Type: TypeDate, Layout: "2006-01-02", Required: true},
+		{Header: "Ship Date", Field: "ShipDate", Type: TypeDate, Layout: "2/1/2006", Required: true},
+		{Header: "Amount", Field: "Amount", Type: TypeAmount, Required: true},
+		{Header: "Channel", Field: "Channel", Type: TypeText, Required: true, Enum: []string{"Offline", "Online"}},
+		{Header: "Notes", Field: "Notes", Type: TypeText},
+		{Header: "2nd Channel", Field: "Column2ndChannel", Type: TypeText, Required: true, Enum: []string{"Offline", "Online"}},
+	}
+
+	for i, e := range expected {
+		c := schema.Columns[i]
+		if c.Header != e.Header || c.Field != e.Field || c.Type != e.Type || c.Layout != e.Layout ||
+			c.Required != e.Required || strings.Join(c.Enum, ",") != strings.Join(e.Enum, ",") {
+			t.Fatalf("\nColumn Mismatch:\nExpected: %+v\nGot: %+v", e, c)
+		}
+	}
+}
+
+func TestInferSample(t *testing.T) {
+	schema, err := Infer(csv.NewReader(strings.NewReader(sample)), "Feed", "feed.csv", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the empty note is past the sample
+	if schema.Rows != 1 || !schema.Columns[5].Required {
+		t.Fatalf("Expected a single row to be sampled, got %d", schema.Rows)
+	}
+
+	if _, err := Infer(csv.NewReader(strings.NewReader("")), "Feed", "feed.csv", 0); err == nil {
+		t.Fatal("Expected an error for a file without headers")
+	}
+}
+
+func TestWriteStruct(t *testing.T) {
+	schema, err := Infer(csv.NewReader(strings.NewReader(sample)), "Feed", "feed.csv", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	if err := schema.WriteStruct(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	// the struct is valid Go
+	if _, err := parser.ParseFile(token.NewFileSet(), "", "package feed\n"+b.String(), 0); err != nil {
+		t.Fatalf("Struct should be valid Go: %v\n%s", err, b.String())
+	}
+
+	for _, line := range []string{
+		"type Feed struct {",
+		"OrderID          int64           `csv:\"Order ID\" processor:\"numeric,required\"`",
+		"OrderDate        time.Time       `csv:\"order date\" processor:\"date=2006-01-02,required\"`\n",
+		"ShipDate         time.Time       `csv:\"Ship Date\" processor:\"date=2/1/2006,required\"`\n",
+		"Amount           decimal.Decimal `csv:\"Amount\" processor:\"amount,required\"`",
+		"Channel          string          `csv:\"Channel\" processor:\"required,enum=Offline|Online\"`",
+		"Notes            string          `csv:\"Notes\"`",
+	} {
+		if !strings.Contains(b.String(), line) {
+			t.Fatalf("Struct should contain %q:\n%s", line, b.String())
+		}
+	}
+}
+
+func TestTagsEnum(t *testing.T) {
+	c := Column{Type: TypeText, Enum: []string{"H", "L"}}
+	if tags := strings.Join(c.Tags(), ","); tags != "enum=H|L" {
+		t.Fatalf("Expected enum=H|L, got %s", tags)
+	}
+
+	// values holding a separator of the tag are left out of it
+	c.Enum = []string{"Dry, Canned", "Fresh"}
+	if tags := c.Tags(); len(tags) != 0 {
+		t.Fatalf("Expected no tags, got %v", tags)
+	}
+}
+
+func TestTagsDate(t *testing.T) {
+	c := Column{Type: TypeDate, Layout: "1/2/2006"}
+	if tags := strings.Join(c.Tags(), ","); tags != "date" || c.GoType() != "time.Time" {
+		t.Fatalf("Expected a date tag, got %s", tags)
+	}
+
+	c.Layout = "2-Jan-06"
+	if tags := strings.Join(c.Tags(), ","); tags != "date=2-Jan-06" || c.GoType() != "time.Time" {
+		t.Fatalf("Expected date=2-Jan-06, got %s", tags)
+	}
+
+	// layouts holding a separator of the tag are left as text
+	c.Layout = "Jan 2, 2006"
+	if tags := c.Tags(); len(tags) != 0 || c.GoType() != "string" {
+		t.Fatalf("Expected a text column, got %v %s", tags, c.GoType())
+	}
+}
+
+func TestInferAmount(t *testing.T) {
+	// values parsed as floats but not as amounts
+	src := "Amount,Ratio\n1.50,NaN\n2,Inf\n3.25,1.5\n"
+
+	schema, err := Infer(csv.NewReader(strings.NewReader(src)), "Feed", "feed.csv", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if schema.Columns[0].Type != TypeAmount || schema.Columns[1].Type != TypeText {
+		t.Fatalf("Expected an amount & a text column, got %+v", schema.Columns)
+	}
+}
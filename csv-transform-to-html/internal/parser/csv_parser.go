@@ -1,14 +1,15 @@
 package parser
 
 import (
+	"bufio"
 	"encoding/csv"
-	"fmt"
+	"errors"
 	"io"
-	"os"
-	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/dele454/medium/csv-transform-to-html/internal/checkpoint"
 	"github.com/dele454/medium/csv-transform-to-html/internal/errs"
 	"github.com/dele454/medium/csv-transform-to-html/internal/report"
 	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
@@ -16,12 +17,15 @@ import (
 
 // Parser list of operations a parser should be able to perform
 type Parser interface {
-	Read(wg *sync.WaitGroup, record chan<- []string, done chan<- bool)
+	Read(wg *sync.WaitGroup, record chan<- utils.Row, done chan<- bool)
 }
 
 // CSVParser parser for parsing and reading from csv files
 type CSVParser struct {
 	reporter report.Reporter
+	dialect  Dialect
+	mapping  utils.HeaderMapping
+	resume   *checkpoint.Checkpoint
 }
 
 // CSVFile
@@ -31,16 +35,63 @@ type CSVFile struct {
 }
 
 // NewCSVParser creates csv parser for parsing & reading sales data
-func NewCSVParser(file string, reporter report.Reporter) Parser {
+//
+// The dialect describes the flavour of the file, its zero
+// value reads comma separated files.
+func NewCSVParser(file string, reporter report.Reporter, dialect Dialect) Parser {
+	return newCSVParser(file, reporter, dialect, nil)
+}
+
+// newCSVParser creates a csv parser resuming from the
+// given checkpoint, if any
+func newCSVParser(file string, reporter report.Reporter, dialect Dialect, resume *checkpoint.Checkpoint) *CSVParser {
 	reporter.SetFilename(file)
 
 	return &CSVParser{
 		reporter: reporter,
+		dialect:  dialect,
+		resume:   resume,
 	}
 }
 
+// OpenCSV opens a csv source file, or stdin, for reading with the
+// given dialect. The file is decompressed and decoded into UTF-8,
+// and its dialect detected from the first lines if requested.
+//
+// The closer closes the file once read.
+func OpenCSV(file string, dialect Dialect) (*csv.Reader, io.Closer, error) {
+	reader, _, f, err := openCSV(file, dialect)
+	return reader, f, err
+}
+
+// openCSV opens a csv source file as OpenCSV does, keeping the
+// text of the rows read so rows failing to be parsed can be
+// handed over as they were read.
+func openCSV(file string, dialect Dialect) (*csv.Reader, *tail, io.Closer, error) {
+	f, err := openSource(file)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// decode the file into UTF-8
+	decoded := decode(bufio.NewReader(f), dialect.Encoding)
+	br := bufio.NewReader(decoded)
+
+	// detect the dialect from the first lines, if requested
+	if dialect.AutoDetect {
+		dialect = dialect.sniff(br)
+	}
+
+	// create csv reader
+	t := &tail{r: br, decoded: decoded}
+	reader := csv.NewReader(t)
+	dialect.apply(reader)
+
+	return reader, t, f, nil
+}
+
 // Read reads from the csv file
-func (c *CSVParser) Read(wg *sync.WaitGroup, record chan<- []string, done chan<- bool) {
+func (c *CSVParser) Read(wg *sync.WaitGroup, record chan<- utils.Row, done chan<- bool) {
 	start := time.Now()
 
 	defer func() {
@@ -51,72 +102,194 @@ func (c *CSVParser) Read(wg *sync.WaitGroup, record chan<- []string, done chan<-
 		wg.Done()
 	}()
 
-	// open file for reading
-	f, err := os.Open(c.reporter.GetFilename())
-	if err != nil {
-		panic(err)
-	}
-	defer f.Close()
+	file := c.reporter.GetFilename()
 
 	// set the headers
 	c.reporter.SetHeaders(utils.GetHeaders())
 
 	// set the file name
-	c.reporter.SetFilename(filepath.Base(c.reporter.GetFilename()))
+	c.reporter.SetFilename(SourceName(file))
 
-	// create csv reader
-	reader := csv.NewReader(f)
+	// open file, or stdin, for reading
+	//
+	// a file which cannot be read fails on its own,
+	// leaving the other files of a batch run to go on
+	reader, text, f, err := openCSV(file, c.dialect)
+	if err != nil {
+		c.reporter.FileFailed(err)
+		done <- true
+		return
+	}
+	defer f.Close()
 
 	// parse headers detected in file
+	//
+	// rows cannot be mapped to fields without valid headers
 	if err := c.parseHeaders(reader); err != nil {
-		c.reporter.AddError(err)
+		c.reporter.FileFailed(err)
+		done <- true
+		return
+	}
+	text.next(reader.InputOffset())
+
+	// lines & offsets are counted from where reading resumes
+	var (
+		base       int
+		baseOffset int64
+	)
+	if c.resume != nil {
+		reader, text, f, err = seekCSV(file, c.resume.Offset, reader)
+		if err != nil {
+			c.reporter.FileFailed(err)
+			done <- true
+			return
+		}
+		defer f.Close()
+
+		base = c.resume.Line
+		baseOffset = c.resume.Offset
 	}
 
 	// read from file
 	for {
 		row, err := reader.Read()
 		if err != nil {
-			if err != io.EOF {
-				c.reporter.AddError(err)
-				c.reporter.RecordFailed()
-				continue
+			if err == io.EOF {
+				break
+			}
+
+			// text of the row as read, for rows
+			// which cannot be split into values
+			raw := text.next(reader.InputOffset())
+
+			// the file cannot be read any further
+			var pe *csv.ParseError
+			if !errors.As(err, &pe) {
+				c.reporter.FileFailed(err)
+				break
 			}
-			break
+
+			re := &errs.RecordError{Line: base + pe.StartLine, Column: pe.Column, Err: pe.Err}
+			c.reporter.AddError(re)
+			c.reporter.RecordFailed()
+
+			// the values read are only kept for rows of the
+			// wrong length, others being cut short at the error
+			if !errors.Is(pe.Err, csv.ErrFieldCount) {
+				row = nil
+			}
+
+			// hand the row over to be quarantined
+			record <- utils.Row{
+				Line:    re.Line,
+				Raw:     row,
+				Text:    raw,
+				Columns: c.mapping,
+				Err:     re,
+				EndLine: base + pe.Line + lines(row),
+				Offset:  text.offsetOf(baseOffset + reader.InputOffset()),
+			}
+			continue
 		}
 
+		line, _ := reader.FieldPos(0)
+		text.next(reader.InputOffset())
+
 		c.reporter.RecordProcessed()
-		record <- row
+		record <- utils.Row{
+			Line:    base + line,
+			Values:  c.mapping.Apply(row),
+			Raw:     row,
+			Columns: c.mapping,
+			EndLine: base + line + lines(row),
+			Offset:  text.offsetOf(baseOffset + reader.InputOffset()),
+		}
 	}
 
 	done <- true
 }
 
+// parseHeaders reads the headers of the file and maps
+// each of them to a field of the SalesRecord.
+//
+// Columns can appear in any order within the file.
 func (c *CSVParser) parseHeaders(reader *csv.Reader) error {
 	// get headers from file
 	headers, err := reader.Read()
 	if err != nil {
-		return err
+		return parseError(err)
 	}
 
-	// check for expected nos of headers
-	if len(c.reporter.GetHeaders()) != len(headers) {
-		return errs.ErrorUnmatachedHeaders
+	line, _ := reader.FieldPos(0)
+
+	c.mapping, err = mapHeaders(headers, line)
+	return err
+}
+
+// lines nos of line breaks within the quoted fields of a row,
+// each making the row span one more line
+func lines(row []string) int {
+	var n int
+	for _, v := range row {
+		n += strings.Count(v, "\n")
 	}
 
-	// check if all expected headers are in source file
-	for _, x := range c.reporter.GetHeaders() {
-		var found bool
+	return n
+}
 
-		for _, y := range headers {
-			if x == y {
-				found = true
-			}
-		}
+// tail keeps the text read off a source file since the last
+// row was read, to hand over the rows failing to be parsed as
+// they were read.
+type tail struct {
+	r   io.Reader
+	buf []byte
+	// offset of the start of buf within the text read
+	offset int64
+	// decoded source file the text is read off
+	decoded io.Reader
+}
 
-		if !found {
-			return fmt.Errorf(errs.ErrorHeaderNotFound.Error(), x)
-		}
+// Read reads from the source file, keeping the text read
+func (t *tail) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	t.buf = append(t.buf, p[:n]...)
+
+	return n, err
+}
+
+// next returns the text read since the previous row up to the
+// given offset, without its line break, and drops it.
+func (t *tail) next(offset int64) string {
+	n := int(offset - t.offset)
+	if n > len(t.buf) {
+		n = len(t.buf)
+	}
+
+	text := string(t.buf[:n])
+	t.buf = t.buf[n:]
+	t.offset = offset
+
+	return strings.TrimRight(text, "\r\n")
+}
+
+// offsetOf returns the given offset of the text read, or zero
+// once it no longer maps back to the source file, a file detected
+// as UTF-8 having fallen back to Windows-1252.
+func (t *tail) offsetOf(offset int64) int64 {
+	if f, ok := t.decoded.(*fallback); ok && f.decoder != nil {
+		return 0
+	}
+
+	return offset
+}
+
+// parseError adds the line & column a csv parse error was
+// found at to the error.
+func parseError(err error) error {
+	var pe *csv.ParseError
+	if !errors.As(err, &pe) {
+		return err
 	}
 
-	return nil
+	return &errs.RecordError{Line: pe.StartLine, Column: pe.Column, Err: pe.Err}
 }
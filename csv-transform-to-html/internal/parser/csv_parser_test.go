@@ -1,9 +1,13 @@
 package parser
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 
+	"github.com/dele454/medium/csv-transform-to-html/internal/errs"
 	"github.com/dele454/medium/csv-transform-to-html/internal/report"
 	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
 )
@@ -12,14 +16,14 @@ func TestCSVRead(t *testing.T) {
 	reporter := report.NewMockReporter()
 
 	path := utils.RootDir()
-	p := NewCSVParser(path+"/internal/testdata/100_sales_records.csv", reporter)
+	p := NewCSVParser(path+"/internal/testdata/100_sales_records.csv", reporter, Dialect{})
 
 	// create waitgroup
 	wg := new(sync.WaitGroup)
 	wg.Add(2)
 
 	// channels for pipeline
-	record := make(chan []string)
+	record := make(chan utils.Row)
 	done := make(chan bool)
 	expected := 100
 
@@ -50,3 +54,128 @@ func TestCSVRead(t *testing.T) {
 		t.Fatalf("\nFormat Mismatch:\nExpected: %v\nGot: %v", expected, counter)
 	}
 }
+
+func TestCSVReadReorderedColumns(t *testing.T) {
+	reporter := report.NewMockReporter()
+
+	path := utils.RootDir()
+	p := NewCSVParser(path+"/internal/testdata/reordered_sales_records.csv", reporter, Dialect{})
+
+	// create waitgroup
+	wg := new(sync.WaitGroup)
+	wg.Add(2)
+
+	// channels for pipeline
+	record := make(chan utils.Row)
+	done := make(chan bool)
+
+	var rows [][]string
+
+	go func() {
+		defer wg.Done()
+
+		for {
+			select {
+			case row := <-record:
+				rows = append(rows, row.Values)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	go p.Read(wg, record, done)
+	wg.Wait()
+
+	if len(reporter.GetErrors()) != 0 {
+		t.Fatalf("Reordered columns should be accepted: %v", reporter.GetErrors())
+	}
+
+	expected := 10
+	if len(rows) != expected {
+		t.Fatalf("\nFormat Mismatch:\nExpected: %v\nGot: %v", expected, len(rows))
+	}
+
+	// values are handed over in the order of the SalesRecord fields
+	first := []string{"Australia and Oceania", "Tuvalu", "Baby Food", "Offline", "H", "5/28/2010", "669165933", "6/27/2010", "9925", "255.28", "159.42", "2533654.00", "1582243.50", "951410.50"}
+	for i, v := range first {
+		if rows[0][i] != v {
+			t.Fatalf("\nField Mismatch:\nExpected: %v\nGot: %v", v, rows[0][i])
+		}
+	}
+}
+
+func TestCSVReadPositions(t *testing.T) {
+	// comment lines are counted
+	_, rows := readRows("semicolon_sales_records.csv", Dialect{Delimiter: ';', Comment: '#'})
+
+	if rows[0].Line != 4 || rows[9].Line != 13 {
+		t.Fatalf("\nLine Mismatch:\nExpected: %v, %v\nGot: %v, %v", 4, 13, rows[0].Line, rows[9].Line)
+	}
+
+	// columns of the values are those of the file
+	_, rows = readRows("reordered_sales_records.csv", Dialect{})
+
+	if len(rows[0].Columns) != len(rows[0].Values) || rows[0].Columns[0] == 0 {
+		t.Fatalf("Columns should map values to the reordered columns: %v", rows[0].Columns)
+	}
+
+	// parse errors carry their line & column
+	reporter, _ := readRows("fail_process_record.csv", Dialect{})
+
+	var re *errs.RecordError
+	if len(reporter.GetErrors()) != 1 || !errors.As(reporter.GetErrors()[0], &re) || re.Line != 2 || re.Column != 1 {
+		t.Fatalf("Expected an error on line 2, column 1, got %v", reporter.GetErrors())
+	}
+
+	// a stray quote is found part way through the line
+	path := filepath.Join(t.TempDir(), "quoted_sales_records.csv")
+	src := "Region,Country,ItemType,SalesChannel,OrderPriority,OrderDate,OrderID,ShipDate,UnitsSold,UnitPrice,UnitCost,TotalRevenue,TotalCost,TotalProfit\n" +
+		"Europe,Rus\"sia\n"
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reporter, _ = readRows(path, Dialect{FieldsPerRecord: FieldsRagged})
+	if len(reporter.GetErrors()) != 1 || !errors.As(reporter.GetErrors()[0], &re) || re.Line != 2 || re.Column != 11 {
+		t.Fatalf("Expected an error on line 2, column 11, got %v", reporter.GetErrors())
+	}
+}
+
+// readRows reads all rows of a file along with their position
+//
+// Relative paths are read from the testdata dir.
+func readRows(file string, dialect Dialect) (*report.Mock, []utils.Row) {
+	if !filepath.IsAbs(file) {
+		file = utils.RootDir() + "/internal/testdata/" + file
+	}
+
+	reporter := report.NewMockReporter()
+	p := NewCSVParser(file, reporter, dialect)
+
+	wg := new(sync.WaitGroup)
+	wg.Add(2)
+
+	record := make(chan utils.Row)
+	done := make(chan bool)
+
+	var rows []utils.Row
+
+	go func() {
+		defer wg.Done()
+
+		for {
+			select {
+			case row := <-record:
+				rows = append(rows, row)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	go p.Read(wg, record, done)
+	wg.Wait()
+
+	return reporter, rows
+}
@@ -0,0 +1,220 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/errs"
+)
+
+// FieldsPolicy how the nos of fields of each row is checked
+type FieldsPolicy string
+
+const (
+	// FieldsStrict every row must have as many fields as the headers
+	FieldsStrict FieldsPolicy = "strict"
+	// FieldsRagged rows can have any nos of fields, missing
+	// fields are left empty and extra fields are ignored
+	FieldsRagged FieldsPolicy = "ragged"
+)
+
+// nos of lines sniffed when auto detecting the dialect
+const sniffLines = 10
+
+// delimiters candidates considered when auto detecting the dialect
+var delimiters = []rune{',', ';', '\t', '|'}
+
+// comments candidates considered when auto detecting the dialect
+var comments = []rune{'#'}
+
+// Dialect describes the flavour of a csv file
+//
+// The zero value reads comma separated files with
+// the defaults of encoding/csv.
+type Dialect struct {
+	// Delimiter separates the fields of a row, a comma if not set
+	Delimiter rune
+	// Comment lines starting with the character are ignored
+	Comment rune
+	// LazyQuotes allows quotes within unquoted fields and
+	// non-doubled quotes within quoted fields
+	LazyQuotes bool
+	// TrimLeadingSpace ignores leading white space of fields
+	TrimLeadingSpace bool
+	// FieldsPerRecord policy for the nos of fields of each row
+	FieldsPerRecord FieldsPolicy
+	// AutoDetect sniffs the delimiter and comment character from
+	// the first lines of the file, unless they are set explicitly
+	AutoDetect bool
+	// Encoding character encoding of the file, detected if not set
+	Encoding Encoding
+}
+
+// ParseDialectRune parses the character given for a delimiter
+// or comment, accepting `\t` or "tab" for a tab.
+func ParseDialectRune(s string) (rune, error) {
+	switch strings.ToLower(s) {
+	case "":
+		return 0, nil
+	case `\t`, "tab":
+		return '\t', nil
+	}
+
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError || size != len(s) {
+		return 0, fmt.Errorf(errs.ErrorInvalidDialectRune.Error(), s)
+	}
+
+	return r, nil
+}
+
+// ParseFieldsPolicy parses the policy given for the nos of fields per row
+func ParseFieldsPolicy(s string) (FieldsPolicy, error) {
+	switch p := FieldsPolicy(strings.ToLower(s)); p {
+	case "", FieldsStrict:
+		return FieldsStrict, nil
+	case FieldsRagged:
+		return p, nil
+	default:
+		return "", fmt.Errorf(errs.ErrorInvalidFieldsPolicy.Error(), s)
+	}
+}
+
+// apply configures the csv reader for the dialect
+func (d Dialect) apply(reader *csv.Reader) {
+	if d.Delimiter != 0 {
+		reader.Comma = d.Delimiter
+	}
+
+	reader.Comment = d.Comment
+	reader.LazyQuotes = d.LazyQuotes
+	reader.TrimLeadingSpace = d.TrimLeadingSpace
+
+	if d.FieldsPerRecord == FieldsRagged {
+		reader.FieldsPerRecord = -1
+	}
+}
+
+// sniff detects the delimiter and comment character from the
+// first lines of the file without consuming them.
+//
+// A delimiter or comment character set explicitly is kept.
+func (d Dialect) sniff(r *bufio.Reader) Dialect {
+	// peek as much as is buffered, an error only means
+	// the file is smaller than the buffer
+	sample, _ := r.Peek(r.Size())
+
+	// the last line of a full buffer may be cut short
+	split := bytes.Split(sample, []byte("\n"))
+	if len(sample) == r.Size() && len(split) > 1 {
+		split = split[:len(split)-1]
+	}
+
+	var lines []string
+	for _, l := range split {
+		if line := strings.TrimRight(string(l), "\r"); strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+
+		if len(lines) == sniffLines {
+			break
+		}
+	}
+
+	if d.Comment == 0 {
+		d.Comment = sniffComment(lines)
+	}
+
+	if d.Comment != 0 {
+		data := lines[:0:0]
+		for _, l := range lines {
+			if !strings.HasPrefix(l, string(d.Comment)) {
+				data = append(data, l)
+			}
+		}
+		lines = data
+	}
+
+	if d.Delimiter == 0 {
+		d.Delimiter = sniffDelimiter(lines)
+	}
+
+	return d
+}
+
+// sniffComment picks the comment character starting any of the lines
+func sniffComment(lines []string) rune {
+	for _, c := range comments {
+		for _, l := range lines {
+			if strings.HasPrefix(l, string(c)) {
+				return c
+			}
+		}
+	}
+
+	return 0
+}
+
+// sniffDelimiter picks the candidate delimiter found the same nos
+// of times on every line, preferring the one found the most.
+//
+// Falls back to the candidate found the most overall.
+func sniffDelimiter(lines []string) rune {
+	var (
+		best       rune
+		bestCount  int
+		fallback   rune
+		fallbackAt int
+	)
+
+	for _, c := range delimiters {
+		consistent := len(lines) > 0
+		total := 0
+
+		for i, l := range lines {
+			n := countUnquoted(l, c)
+			total += n
+
+			if n == 0 || (i > 0 && n != countUnquoted(lines[0], c)) {
+				consistent = false
+			}
+		}
+
+		if consistent && total > bestCount {
+			best, bestCount = c, total
+		}
+
+		if total > fallbackAt {
+			fallback, fallbackAt = c, total
+		}
+	}
+
+	if best != 0 {
+		return best
+	}
+
+	return fallback
+}
+
+// countUnquoted counts the occurrences of a character outside of quotes
+func countUnquoted(line string, c rune) int {
+	var (
+		n      int
+		quoted bool
+	)
+
+	for _, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == c && !quoted:
+			n++
+		}
+	}
+
+	return n
+}
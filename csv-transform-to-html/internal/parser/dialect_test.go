@@ -0,0 +1,145 @@
+package parser
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/report"
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
+)
+
+// readAll reads the values of all rows of a file with the given
+// dialect, skipping the rows the parser failed to read.
+func readAll(file string, dialect Dialect) (*report.Mock, [][]string) {
+	reporter, rows := readRows(file, dialect)
+
+	var values [][]string
+	for _, row := range rows {
+		if row.Err == nil {
+			values = append(values, row.Values)
+		}
+	}
+
+	return reporter, values
+}
+
+func TestCSVReadDialect(t *testing.T) {
+	reporter, rows := readAll("semicolon_sales_records.csv", Dialect{Delimiter: ';', Comment: '#'})
+
+	if len(reporter.GetErrors()) != 0 {
+		t.Fatalf("Semicolon separated file should be accepted: %v", reporter.GetErrors())
+	}
+
+	expected := 10
+	if len(rows) != expected {
+		t.Fatalf("\nFormat Mismatch:\nExpected: %v\nGot: %v", expected, len(rows))
+	}
+
+	if rows[0][1] != "Tuvalu" {
+		t.Fatalf("\nField Mismatch:\nExpected: %v\nGot: %v", "Tuvalu", rows[0][1])
+	}
+}
+
+func TestCSVReadDialectAutoDetect(t *testing.T) {
+	reporter, rows := readAll("semicolon_sales_records.csv", Dialect{AutoDetect: true})
+
+	if len(reporter.GetErrors()) != 0 {
+		t.Fatalf("Dialect should be detected: %v", reporter.GetErrors())
+	}
+
+	expected := 10
+	if len(rows) != expected {
+		t.Fatalf("\nFormat Mismatch:\nExpected: %v\nGot: %v", expected, len(rows))
+	}
+
+	// comma separated files are unaffected
+	reporter, rows = readAll("100_sales_records.csv", Dialect{AutoDetect: true})
+
+	expected = 100
+	if len(reporter.GetErrors()) != 0 || len(rows) != expected {
+		t.Fatalf("\nFormat Mismatch:\nExpected: %v\nGot: %v %v", expected, len(rows), reporter.GetErrors())
+	}
+}
+
+func TestCSVReadFieldsPolicy(t *testing.T) {
+	// rows not matching the headers fail by default
+	reporter, rows := readAll("ragged_sales_records.csv", Dialect{})
+
+	if len(rows) != 3 || reporter.GetTotalFailedRecords() != 2 {
+		t.Fatalf("\nFormat Mismatch:\nExpected: %v rows, %v failed\nGot: %v rows, %v failed",
+			3, 2, len(rows), reporter.GetTotalFailedRecords())
+	}
+
+	// failed rows are handed over with the values read
+	_, all := readRows("ragged_sales_records.csv", Dialect{})
+	if len(all) != 5 || all[1].Err == nil || len(all[1].Raw) != len(utils.GetHeaders())+1 {
+		t.Fatalf("Failed rows should be handed over for quarantine: %+v", all)
+	}
+
+	// ragged rows are padded or cut to the headers
+	reporter, rows = readAll("ragged_sales_records.csv", Dialect{FieldsPerRecord: FieldsRagged})
+
+	if len(rows) != 5 || len(reporter.GetErrors()) != 0 {
+		t.Fatalf("\nFormat Mismatch:\nExpected: %v rows\nGot: %v rows %v", 5, len(rows), reporter.GetErrors())
+	}
+
+	for _, row := range rows {
+		if len(row) != len(utils.GetHeaders()) {
+			t.Fatalf("\nFormat Mismatch:\nExpected: %v fields\nGot: %v", len(utils.GetHeaders()), len(row))
+		}
+	}
+
+	if last := rows[3][len(rows[3])-1]; last != "" {
+		t.Fatalf("Missing field should be empty, got %q", last)
+	}
+}
+
+func TestSniff(t *testing.T) {
+	tests := []struct {
+		name      string
+		sample    string
+		delimiter rune
+		comment   rune
+	}{
+		{"comma", "a,b,c\n1,2,3\n", ',', 0},
+		{"tab", "a\tb\tc\n1\t2\t3\n", '\t', 0},
+		{"pipe with quoted commas", "a|b\n\"1,5\"|2\n\"3,1\"|4\n", '|', 0},
+		{"semicolon with comments", "# export\na;b\n1;2\n", ';', '#'},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Dialect{}.sniff(bufio.NewReader(strings.NewReader(tt.sample)))
+
+			if d.Delimiter != tt.delimiter || d.Comment != tt.comment {
+				t.Fatalf("\nDialect Mismatch:\nExpected: %q %q\nGot: %q %q", tt.delimiter, tt.comment, d.Delimiter, d.Comment)
+			}
+		})
+	}
+
+	// explicit settings are kept
+	d := Dialect{Delimiter: ','}.sniff(bufio.NewReader(strings.NewReader("a;b\n1;2\n")))
+	if d.Delimiter != ',' {
+		t.Fatalf("\nDialect Mismatch:\nExpected: %q\nGot: %q", ',', d.Delimiter)
+	}
+}
+
+func TestParseDialectRune(t *testing.T) {
+	tests := map[string]rune{"": 0, ";": ';', "tab": '\t', `\t`: '\t', "|": '|'}
+
+	for s, expected := range tests {
+		r, err := ParseDialectRune(s)
+		if err != nil || r != expected {
+			t.Fatalf("\nRune Mismatch for %q:\nExpected: %q\nGot: %q %v", s, expected, r, err)
+		}
+	}
+
+	if _, err := ParseDialectRune(";;"); err == nil {
+		t.Fatal("Expected an error for more than one character")
+	}
+
+	if _, err := ParseFieldsPolicy("loose"); err == nil {
+		t.Fatal("Expected an error for an unknown policy")
+	}
+}
@@ -0,0 +1,212 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/errs"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Encoding character encoding of a source file
+type Encoding string
+
+// Supported encodings, a source file is decoded into UTF-8
+const (
+	// EncodingAuto detects the encoding from the first bytes of the file
+	EncodingAuto        Encoding = ""
+	EncodingUTF8        Encoding = "utf-8"
+	EncodingUTF16LE     Encoding = "utf-16le"
+	EncodingUTF16BE     Encoding = "utf-16be"
+	EncodingWindows1252 Encoding = "windows-1252"
+	EncodingISO88591    Encoding = "iso-8859-1"
+)
+
+// aliases of the supported encodings
+var encodingAliases = map[string]Encoding{
+	"auto":        EncodingAuto,
+	"utf8":        EncodingUTF8,
+	"utf16le":     EncodingUTF16LE,
+	"utf16be":     EncodingUTF16BE,
+	"cp1252":      EncodingWindows1252,
+	"windows1252": EncodingWindows1252,
+	"latin1":      EncodingISO88591,
+	"iso88591":    EncodingISO88591,
+}
+
+// byte order marks
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+// ParseEncoding parses the encoding given for a source file
+func ParseEncoding(s string) (Encoding, error) {
+	e := Encoding(strings.ToLower(s))
+	switch e {
+	case EncodingAuto, EncodingUTF8, EncodingUTF16LE, EncodingUTF16BE, EncodingWindows1252, EncodingISO88591:
+		return e, nil
+	}
+
+	if e, ok := encodingAliases[strings.ReplaceAll(string(e), "-", "")]; ok {
+		return e, nil
+	}
+
+	return "", fmt.Errorf(errs.ErrorUnsupportedEncoding.Error(), s)
+}
+
+// decode decodes a source file into UTF-8, stripping any byte
+// order mark, detecting its encoding first unless forced.
+func decode(r *bufio.Reader, enc Encoding) io.Reader {
+	detected := enc == EncodingAuto
+	if detected {
+		enc = detectEncoding(r)
+	}
+
+	var e encoding.Encoding
+	switch enc {
+	case EncodingUTF16LE:
+		e = unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)
+	case EncodingUTF16BE:
+		e = unicode.UTF16(unicode.BigEndian, unicode.UseBOM)
+	case EncodingWindows1252:
+		e = charmap.Windows1252
+	case EncodingISO88591:
+		e = charmap.ISO8859_1
+	default:
+		// the BOM of a UTF-8 file would be read as part
+		// of the first header
+		if head, _ := r.Peek(len(bomUTF8)); bytes.Equal(head, bomUTF8) {
+			r.Discard(len(bomUTF8))
+			return r
+		}
+
+		// only the first bytes were sampled to detect it
+		if detected {
+			return &fallback{r: r}
+		}
+		return r
+	}
+
+	return transform.NewReader(r, e.NewDecoder())
+}
+
+// detectEncoding detects the encoding of a source file from its byte
+// order mark, or going by its first bytes without one.
+//
+// Text with a NUL byte in every other position is taken for UTF-16,
+// and text which isn't valid UTF-8 for Windows-1252, a superset of
+// the printable characters of ISO-8859-1.
+func detectEncoding(r *bufio.Reader) Encoding {
+	// peek as much as is buffered, an error only means
+	// the file is smaller than the buffer
+	sample, _ := r.Peek(r.Size())
+
+	switch {
+	case bytes.HasPrefix(sample, bomUTF8):
+		return EncodingUTF8
+	case bytes.HasPrefix(sample, bomUTF16LE):
+		return EncodingUTF16LE
+	case bytes.HasPrefix(sample, bomUTF16BE):
+		return EncodingUTF16BE
+	}
+
+	var even, odd int
+	for i, b := range sample {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			even++
+		} else {
+			odd++
+		}
+	}
+
+	switch half := len(sample) / 4; {
+	case len(sample) >= 2 && odd > half:
+		return EncodingUTF16LE
+	case len(sample) >= 2 && even > half:
+		return EncodingUTF16BE
+	}
+
+	// a rune may be cut short at the end of a full buffer
+	if len(sample) == r.Size() {
+		for i := len(sample) - 1; i >= 0 && i >= len(sample)-utf8.UTFMax; i-- {
+			if utf8.RuneStart(sample[i]) {
+				if !utf8.FullRune(sample[i:]) {
+					sample = sample[:i]
+				}
+				break
+			}
+		}
+	}
+
+	if utf8.Valid(sample) {
+		return EncodingUTF8
+	}
+
+	return EncodingWindows1252
+}
+
+// fallback reads a source file detected as UTF-8 from its first
+// bytes, decoding it as Windows-1252 from the first byte which
+// isn't valid UTF-8 onwards.
+//
+// Windows-1252 text only differs from UTF-8 past ASCII, so a file
+// whose first bytes were all ASCII is decoded the same either way.
+type fallback struct {
+	r *bufio.Reader
+	// decoder of the rest of the file once switched to Windows-1252
+	decoder io.Reader
+}
+
+// Read reads the valid UTF-8 text off the file, switching to
+// Windows-1252 once none is left
+func (f *fallback) Read(p []byte) (int, error) {
+	if f.decoder != nil {
+		return f.decoder.Read(p)
+	}
+
+	// peek a full buffer, so a rune is only cut short at the end
+	// of the file. An error only means the file ends within it.
+	buf, err := f.r.Peek(f.r.Size())
+	if len(buf) == 0 {
+		return 0, err
+	}
+
+	var n int
+	for n < len(buf) {
+		r, width := utf8.DecodeRune(buf[n:])
+		if r == utf8.RuneError && width == 1 {
+			break
+		}
+		// the rune doesn't fit in what is left of p
+		if n+width > len(p) {
+			if n == 0 {
+				return 0, io.ErrShortBuffer
+			}
+			break
+		}
+		n += width
+	}
+
+	// the valid text is read before switching
+	if n > 0 {
+		copy(p, buf[:n])
+		f.r.Discard(n)
+		return n, nil
+	}
+
+	// the next byte isn't valid UTF-8
+	f.decoder = transform.NewReader(f.r, charmap.Windows1252.NewDecoder())
+	return f.decoder.Read(p)
+}
@@ -0,0 +1,148 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// sample rows holding characters outside of ASCII
+const encodingSample = "Region,Country,ItemType,SalesChannel,OrderPriority,OrderDate,OrderID,ShipDate,UnitsSold,UnitPrice,UnitCost,TotalRevenue,TotalCost,TotalProfit\r\n" +
+	"Sub-Saharan Africa,Côte d'Ivoire,Baby Food,Offline,H,5/28/2010,669165933,6/27/2010,9925,255.28,159.42,2533654.00,1582243.50,951410.50\r\n" +
+	"Europe,Österreich,Cereal,Online,C,8/22/2012,963881480,9/15/2012,2804,205.70,117.11,576782.80,328376.44,248406.36\r\n"
+
+// encodings of the sample
+func encodedSamples(t *testing.T) map[string][]byte {
+	utf16le, err := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder().String(encodingSample)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	utf16be, err := unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM).NewEncoder().String(encodingSample)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cp1252, err := charmap.Windows1252.NewEncoder().String(encodingSample)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return map[string][]byte{
+		"utf-8":                []byte(encodingSample),
+		"utf-8 with bom":       append([]byte{0xef, 0xbb, 0xbf}, encodingSample...),
+		"utf-16le with bom":    []byte(utf16le),
+		"utf-16be without bom": []byte(utf16be),
+		"windows-1252":         []byte(cp1252),
+	}
+}
+
+func TestDecode(t *testing.T) {
+	for name, b := range encodedSamples(t) {
+		t.Run(name, func(t *testing.T) {
+			got, err := io.ReadAll(decode(bufio.NewReader(bytes.NewReader(b)), EncodingAuto))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != encodingSample {
+				t.Fatalf("\nDecode Mismatch:\nExpected: %q\nGot: %q", encodingSample, got)
+			}
+		})
+	}
+
+	// a forced encoding takes precedence over detection
+	latin1, _ := charmap.ISO8859_1.NewEncoder().String("Côte")
+	got, _ := io.ReadAll(decode(bufio.NewReader(bytes.NewReader([]byte(latin1))), EncodingISO88591))
+	if string(got) != "Côte" {
+		t.Fatalf("\nDecode Mismatch:\nExpected: %q\nGot: %q", "Côte", got)
+	}
+}
+
+func TestDecodeFallback(t *testing.T) {
+	// ascii rows filling more than the sampled buffer
+	ascii := encodingSample[:strings.Index(encodingSample, "\r\n")+2]
+	for len(ascii) <= 2*4096 {
+		ascii += "Europe,Austria,Cereal,Online,C,8/22/2012,963881480,9/15/2012,2804,205.70,117.11,576782.80,328376.44,248406.36\r\n"
+	}
+
+	// Windows-1252 past the sample is decoded rather than
+	// replaced with U+FFFD
+	cp1252, err := charmap.Windows1252.NewEncoder().String("Europe,Österreich,Café\r\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := io.ReadAll(decode(bufio.NewReader(strings.NewReader(ascii+cp1252)), EncodingAuto))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := ascii + "Europe,Österreich,Café\r\n"; string(got) != expected {
+		t.Fatalf("\nDecode Mismatch:\nExpected: %q\nGot: %q", expected[len(expected)-40:], got[len(got)-40:])
+	}
+
+	// UTF-8 runes straddling the end of a buffer are kept whole
+	runes := strings.Repeat("Österreich,Côte d'Ivoire\r\n", 500)
+
+	got, err = io.ReadAll(decode(bufio.NewReader(strings.NewReader(ascii+runes)), EncodingAuto))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(got) != ascii+runes {
+		t.Fatal("UTF-8 past the sample should be read as is")
+	}
+}
+
+func TestCSVReadEncodings(t *testing.T) {
+	dir := t.TempDir()
+
+	for name, b := range encodedSamples(t) {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, "sales.csv")
+			if err := os.WriteFile(path, b, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			reporter, rows := readAll(path, Dialect{})
+
+			if len(reporter.GetErrors()) != 0 {
+				t.Fatalf("Headers should be valid: %v", reporter.GetErrors())
+			}
+
+			if len(rows) != 2 || rows[0][1] != "Côte d'Ivoire" || rows[1][1] != "Österreich" {
+				t.Fatalf("Countries should be decoded, got %v", rows)
+			}
+		})
+	}
+}
+
+func TestParseEncoding(t *testing.T) {
+	tests := map[string]Encoding{
+		"":             EncodingAuto,
+		"auto":         EncodingAuto,
+		"UTF-8":        EncodingUTF8,
+		"utf16le":      EncodingUTF16LE,
+		"cp1252":       EncodingWindows1252,
+		"Windows-1252": EncodingWindows1252,
+		"latin1":       EncodingISO88591,
+	}
+
+	for s, expected := range tests {
+		if e, err := ParseEncoding(s); err != nil || e != expected {
+			t.Fatalf("\nEncoding Mismatch for %q:\nExpected: %v\nGot: %v %v", s, expected, e, err)
+		}
+	}
+
+	if _, err := ParseEncoding("ebcdic"); err == nil {
+		t.Fatal("Expected an error for an unsupported encoding")
+	}
+}
@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/checkpoint"
+	"github.com/dele454/medium/csv-transform-to-html/internal/errs"
+	"github.com/dele454/medium/csv-transform-to-html/internal/report"
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
+)
+
+// Options tune how a source file is read
+type Options struct {
+	// Dialect flavour of a csv source file
+	Dialect Dialect
+	// Sheet sheet of a workbook source file to read, the
+	// first sheet if not set
+	Sheet string
+	// Resume checkpoint of an interrupted transformation of
+	// a csv source file to resume reading from, if any
+	Resume *checkpoint.Checkpoint
+}
+
+// extensions of the workbook source files
+var workbookExts = []string{".xlsx", ".xlsm"}
+
+// NewParser creates a parser for the source file going by its
+// extension, workbooks are read by an XLSXParser and any other
+// file, or stdin, by a CSVParser.
+func NewParser(file string, reporter report.Reporter, opts Options) Parser {
+	if IsWorkbook(file) {
+		return NewXLSXParser(file, reporter, opts.Sheet)
+	}
+
+	return newCSVParser(file, reporter, opts.Dialect, opts.Resume)
+}
+
+// IsWorkbook reports if the file is an Excel workbook,
+// compressed or not
+func IsWorkbook(file string) bool {
+	ext := strings.ToLower(filepath.Ext(SourceName(file)))
+	for _, e := range workbookExts {
+		if ext == e {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mapHeaders maps the headers found on the given line of the
+// source file to the fields of the SalesRecord.
+func mapHeaders(headers []string, line int) (utils.HeaderMapping, error) {
+	mapping, err := utils.MapHeaders(headers)
+	if err != nil {
+		return nil, &errs.RecordError{Line: line, Err: err}
+	}
+
+	return mapping, nil
+}
@@ -0,0 +1,90 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/errs"
+)
+
+// Resumable reports why reading a source file cannot resume part
+// way through, if so.
+//
+// Only plain csv files encoded in UTF-8 can be seeked into, the
+// offsets of a decompressed or transcoded file not mapping back to
+// the file itself.
+func Resumable(file string, dialect Dialect) error {
+	unsupported := func(reason string) error {
+		return fmt.Errorf(errs.ErrorResumeUnsupported.Error(), SourceName(file), reason)
+	}
+
+	if file == Stdin {
+		return unsupported("stdin cannot be seeked into")
+	}
+
+	if IsWorkbook(file) {
+		return unsupported("workbooks are not read row by row")
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	head, _ := br.Peek(4)
+	if detectCompression(file, head) != nil {
+		return unsupported("compressed files cannot be seeked into")
+	}
+
+	enc := dialect.Encoding
+	if enc == EncodingAuto {
+		enc = detectEncoding(br)
+	}
+
+	if enc != EncodingUTF8 {
+		return unsupported("only utf-8 files can be seeked into")
+	}
+
+	return nil
+}
+
+// seekCSV reopens a csv source file at the given offset of its
+// decoded content, reading it with the settings of the reader
+// which read its headers.
+func seekCSV(file string, offset int64, settings *csv.Reader) (*csv.Reader, *tail, io.Closer, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// the offset doesn't account for the BOM
+	// stripped off when decoding
+	head := make([]byte, len(bomUTF8))
+	if n, _ := io.ReadFull(f, head); bytes.Equal(head[:n], bomUTF8) {
+		offset += int64(len(bomUTF8))
+	}
+
+	if _, err = f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, nil, nil, err
+	}
+
+	// the file may still fall back to Windows-1252
+	// past the offset, as it did when first read
+	decoded := &fallback{r: bufio.NewReader(f)}
+	t := &tail{r: decoded, decoded: decoded}
+	reader := csv.NewReader(t)
+	reader.Comma = settings.Comma
+	reader.Comment = settings.Comment
+	reader.LazyQuotes = settings.LazyQuotes
+	reader.TrimLeadingSpace = settings.TrimLeadingSpace
+	reader.FieldsPerRecord = settings.FieldsPerRecord
+
+	return reader, t, f, nil
+}
@@ -0,0 +1,153 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Stdin name of the source file read from stdin
+const Stdin = "-"
+
+// stdinName name reported for a source read from stdin
+const stdinName = "stdin"
+
+// compression a compression format of a source file
+type compression struct {
+	ext   string
+	magic []byte
+	open  func(r io.Reader) (io.ReadCloser, error)
+}
+
+// compressions formats transparently decompressed when reading
+var compressions = []compression{
+	{
+		ext:   ".gz",
+		magic: []byte{0x1f, 0x8b},
+		open: func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+	},
+	{
+		ext:   ".bz2",
+		magic: []byte("BZh"),
+		open: func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(bzip2.NewReader(r)), nil
+		},
+	},
+	{
+		ext:   ".zst",
+		magic: []byte{0x28, 0xb5, 0x2f, 0xfd},
+		open: func(r io.Reader) (io.ReadCloser, error) {
+			d, err := zstd.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			return d.IOReadCloser(), nil
+		},
+	},
+}
+
+// source a source file being read, decompressed if need be
+type source struct {
+	io.Reader
+	closers []io.Closer
+}
+
+// openSource opens the named source file for reading, or stdin
+// for "-".
+//
+// Gzip, bzip2 & zstd compressed files are decompressed going by
+// their magic bytes, or their extension failing that.
+func openSource(name string) (*source, error) {
+	var f io.ReadCloser = io.NopCloser(os.Stdin)
+
+	if name != Stdin {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		f = file
+	}
+
+	s := &source{closers: []io.Closer{f}}
+	br := bufio.NewReader(f)
+
+	// an error only means the file is shorter than the magic bytes
+	head, _ := br.Peek(4)
+
+	c := detectCompression(name, head)
+	if c == nil {
+		s.Reader = br
+		return s, nil
+	}
+
+	r, err := c.open(br)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	s.Reader = r
+	s.closers = append([]io.Closer{r}, s.closers...)
+
+	return s, nil
+}
+
+// Close closes the decompressor and the file
+func (s *source) Close() error {
+	var err error
+	for _, c := range s.closers {
+		if e := c.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// detectCompression detects the compression of a file from
+// its first bytes, or its extension failing that.
+func detectCompression(name string, head []byte) *compression {
+	for i, c := range compressions {
+		if bytes.HasPrefix(head, c.magic) {
+			return &compressions[i]
+		}
+	}
+
+	ext := strings.ToLower(filepath.Ext(name))
+	for i, c := range compressions {
+		if ext == c.ext {
+			return &compressions[i]
+		}
+	}
+
+	return nil
+}
+
+// SourceName name of a source file as reported, without
+// its compression extension.
+//
+// e.g sales.csv.gz is reported as sales.csv
+func SourceName(name string) string {
+	if name == Stdin {
+		return stdinName
+	}
+
+	name = filepath.Base(name)
+	ext := filepath.Ext(name)
+	for _, c := range compressions {
+		if strings.EqualFold(ext, c.ext) {
+			return strings.TrimSuffix(name, ext)
+		}
+	}
+
+	return name
+}
@@ -0,0 +1,114 @@
+package parser
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
+	"github.com/klauspost/compress/zstd"
+)
+
+// compress writes the plain sales records to a file in dir
+// through the given compressor.
+func compress(t *testing.T, dir, name string, wrap func(io.Writer) io.WriteCloser) string {
+	src, err := os.ReadFile(utils.RootDir() + "/internal/testdata/100_sales_records.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w := wrap(f)
+	if _, err = w.Write(src); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestCSVReadCompressed(t *testing.T) {
+	dir := t.TempDir()
+
+	gz := func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }
+	zst := func(w io.Writer) io.WriteCloser {
+		e, _ := zstd.NewWriter(w)
+		return e
+	}
+
+	tests := []struct {
+		name string
+		file string
+	}{
+		{"gzip", compress(t, dir, "sales.csv.gz", gz)},
+		{"zstd", compress(t, dir, "sales.csv.zst", zst)},
+		{"gzip by magic bytes", compress(t, dir, "sales.export", gz)},
+		{"bzip2", utils.RootDir() + "/internal/testdata/100_sales_records.csv.bz2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reporter, rows := readAll(tt.file, Dialect{})
+
+			expected := 100
+			if len(reporter.GetErrors()) != 0 || len(rows) != expected {
+				t.Fatalf("\nFormat Mismatch:\nExpected: %v\nGot: %v %v", expected, len(rows), reporter.GetErrors())
+			}
+		})
+	}
+}
+
+func TestCSVReadStdin(t *testing.T) {
+	f, err := os.Open(utils.RootDir() + "/internal/testdata/100_sales_records.csv.bz2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	stdin := os.Stdin
+	os.Stdin = f
+	defer func() { os.Stdin = stdin }()
+
+	s, err := openSource(Stdin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	b, err := io.ReadAll(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, _ := os.ReadFile(utils.RootDir() + "/internal/testdata/100_sales_records.csv")
+	if string(b) != string(src) {
+		t.Fatal("Content read from stdin doesn't match the source file")
+	}
+}
+
+func TestSourceName(t *testing.T) {
+	tests := map[string]string{
+		"-":                     "stdin",
+		"/tmp/sales.csv":        "sales.csv",
+		"/tmp/sales.csv.gz":     "sales.csv",
+		"exports/sales.csv.BZ2": "sales.csv",
+		"sales.csv.zst":         "sales.csv",
+	}
+
+	for name, expected := range tests {
+		if got := SourceName(name); got != expected {
+			t.Fatalf("\nName Mismatch:\nExpected: %v\nGot: %v", expected, got)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package parser
+
+import (
+	"encoding/csv"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/errs"
+	"github.com/dele454/medium/csv-transform-to-html/internal/report"
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
+	"github.com/xuri/excelize/v2"
+)
+
+// XLSXParser parser for reading sales data from a sheet
+// of an Excel workbook
+//
+// Cells are read as stored rather than as displayed, so numbers
+// keep their precision and dates stored as serial numbers are
+// converted to the date format expected by the processor,
+// whatever their display format.
+type XLSXParser struct {
+	reporter report.Reporter
+	sheet    string
+	mapping  utils.HeaderMapping
+	date1904 bool
+}
+
+// NewXLSXParser creates a workbook parser for reading sales data
+// from the given sheet, or the first sheet if empty.
+func NewXLSXParser(file string, reporter report.Reporter, sheet string) Parser {
+	reporter.SetFilename(file)
+
+	return &XLSXParser{
+		reporter: reporter,
+		sheet:    sheet,
+	}
+}
+
+// Read reads from the sheet of the workbook
+func (x *XLSXParser) Read(wg *sync.WaitGroup, record chan<- utils.Row, done chan<- bool) {
+	start := time.Now()
+
+	defer func() {
+		close(done)
+		close(record)
+		x.reporter.AddDuration(time.Since(start).Seconds())
+
+		wg.Done()
+	}()
+
+	file := x.reporter.GetFilename()
+
+	// set the headers
+	x.reporter.SetHeaders(utils.GetHeaders())
+
+	// set the file name
+	x.reporter.SetFilename(SourceName(file))
+
+	// open file, or stdin, for reading
+	//
+	// a file which cannot be read fails on its own,
+	// leaving the other files of a batch run to go on
+	src, err := openSource(file)
+	if err != nil {
+		x.reporter.FileFailed(err)
+		done <- true
+		return
+	}
+	defer src.Close()
+
+	f, err := excelize.OpenReader(src)
+	if err != nil {
+		x.reporter.FileFailed(err)
+		done <- true
+		return
+	}
+	defer f.Close()
+
+	rows, err := x.rows(f)
+	if err != nil {
+		x.reporter.FileFailed(err)
+		done <- true
+		return
+	}
+	defer rows.Close()
+
+	var (
+		line  int
+		dates = dateFields()
+	)
+
+	for rows.Next() {
+		line++
+
+		row, err := rows.Columns(excelize.Options{RawCellValue: true})
+		if err != nil {
+			x.reporter.FileFailed(err)
+			break
+		}
+
+		// empty rows are skipped as a csv reader would
+		row = trimRow(row)
+		if len(row) == 0 {
+			continue
+		}
+
+		// parse headers detected in sheet
+		//
+		// rows cannot be mapped to fields without valid headers
+		if x.mapping == nil {
+			if x.mapping, err = mapHeaders(row, line); err != nil {
+				x.reporter.FileFailed(err)
+				break
+			}
+			continue
+		}
+
+		// rows are read as strictly as a csv file's
+		if len(row) > len(x.mapping) {
+			re := &errs.RecordError{Line: line, Column: 1, Err: csv.ErrFieldCount}
+			x.reporter.AddError(re)
+			x.reporter.RecordFailed()
+
+			record <- utils.Row{Line: line, Raw: row, Columns: x.mapping, Err: re}
+			continue
+		}
+
+		values := x.mapping.Apply(row)
+		for i, v := range values {
+			if dates[i] {
+				values[i] = x.date(v)
+			}
+		}
+
+		x.reporter.RecordProcessed()
+		record <- utils.Row{
+			Line:    line,
+			Values:  values,
+			Raw:     row,
+			Columns: x.mapping,
+		}
+	}
+
+	if err := rows.Error(); err != nil {
+		x.reporter.FileFailed(err)
+	}
+
+	done <- true
+}
+
+// rows opens the sheet to read, the first sheet by default
+func (x *XLSXParser) rows(f *excelize.File) (*excelize.Rows, error) {
+	sheets := f.GetSheetList()
+
+	sheet := x.sheet
+	if sheet == "" && len(sheets) > 0 {
+		sheet = sheets[0]
+	}
+
+	if idx, err := f.GetSheetIndex(sheet); err != nil || idx < 0 {
+		return nil, fmt.Errorf(errs.ErrorSheetNotFound.Error(), sheet)
+	}
+
+	props, err := f.GetWorkbookProps()
+	if err != nil {
+		return nil, err
+	}
+	x.date1904 = props.Date1904 != nil && *props.Date1904
+
+	return f.Rows(sheet)
+}
+
+// date converts a date stored as a serial number to the date
+// format expected by the processor.
+//
+// Dates stored as text are left as is.
+func (x *XLSXParser) date(v string) string {
+	serial, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return v
+	}
+
+	d, err := excelize.ExcelDateToTime(serial, x.date1904)
+	if err != nil {
+		return v
+	}
+
+	return d.Format(utils.DateLayout)
+}
+
+// trimRow drops the trailing empty cells of a row
+func trimRow(row []string) []string {
+	for len(row) > 0 && strings.TrimSpace(row[len(row)-1]) == "" {
+		row = row[:len(row)-1]
+	}
+
+	return row
+}
+
+// dateFields flags the SalesRecord fields, in struct
+// order, holding a date
+func dateFields() []bool {
+	s := reflect.TypeOf(utils.SalesRecord{})
+
+	dates := make([]bool, s.NumField())
+	for i := range dates {
+		for _, t := range utils.SplitTags(s.Field(i).Tag.Get("processor")) {
+			if t == "date" {
+				dates[i] = true
+			}
+		}
+	}
+
+	return dates
+}
@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/report"
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
+)
+
+// readSheet reads all rows of a sheet of the testdata workbook
+func readSheet(sheet string) (*report.Mock, []utils.Row) {
+	reporter := report.NewMockReporter()
+	p := NewXLSXParser(utils.RootDir()+"/internal/testdata/sales_records.xlsx", reporter, sheet)
+
+	// create waitgroup
+	wg := new(sync.WaitGroup)
+	wg.Add(2)
+
+	// channels for pipeline
+	record := make(chan utils.Row)
+	done := make(chan bool)
+
+	var rows []utils.Row
+
+	go func() {
+		defer wg.Done()
+
+		for {
+			select {
+			case row := <-record:
+				rows = append(rows, row)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	go p.Read(wg, record, done)
+	wg.Wait()
+
+	return reporter, rows
+}
+
+func TestXLSXRead(t *testing.T) {
+	for _, sheet := range []string{"", "Sales", "Reordered"} {
+		t.Run(sheet, func(t *testing.T) {
+			reporter, rows := readSheet(sheet)
+
+			if len(reporter.GetErrors()) != 0 {
+				t.Fatalf("Sheet should be read: %v", reporter.GetErrors())
+			}
+
+			expected := 10
+			if len(rows) != expected {
+				t.Fatalf("\nFormat Mismatch:\nExpected: %v\nGot: %v", expected, len(rows))
+			}
+
+			// dates are converted whatever their display format
+			first := []string{"Australia and Oceania", "Tuvalu", "Baby Food", "Offline", "H", "5/28/2010", "669165933", "6/27/2010", "9925", "255.28", "159.42", "2533654", "1582243.5", "951410.5"}
+			for i, v := range first {
+				if rows[0].Values[i] != v {
+					t.Fatalf("\nField Mismatch:\nExpected: %v\nGot: %v", v, rows[0].Values[i])
+				}
+			}
+
+			if rows[0].Line != 2 {
+				t.Fatalf("\nLine Mismatch:\nExpected: %v\nGot: %v", 2, rows[0].Line)
+			}
+
+			// values pass the processor's validation
+			var p utils.Processor
+			for _, row := range rows {
+				if _, err := p.Unmarshal(row.Values, utils.SalesRecord{}); err != nil {
+					t.Fatalf("Line %d should be valid: %v", row.Line, err)
+				}
+			}
+		})
+	}
+}
+
+func TestXLSXReadMissingSheet(t *testing.T) {
+	reporter, rows := readSheet("Missing")
+
+	if len(rows) != 0 || len(reporter.GetErrors()) != 1 {
+		t.Fatalf("Expected a missing sheet error, got %d rows %v", len(rows), reporter.GetErrors())
+	}
+}
+
+func TestNewParser(t *testing.T) {
+	reporter := report.NewMockReporter()
+
+	if _, ok := NewParser("sales.XLSX", reporter, Options{}).(*XLSXParser); !ok {
+		t.Fatal("Workbooks should be read by an XLSXParser")
+	}
+
+	if _, ok := NewParser("sales.csv.gz", reporter, Options{}).(*CSVParser); !ok {
+		t.Fatal("Csv files should be read by a CSVParser")
+	}
+}
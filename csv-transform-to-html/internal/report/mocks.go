@@ -13,6 +13,7 @@ type Mock struct {
 	TotalProcessedRecords   int
 	TotalTransformedRecords int
 	TotalFailedRecords      int
+	Failed                  bool
 	Errors                  []error
 	Duration                float64
 	DurationDisplay         string
@@ -49,6 +50,13 @@ func (m *Mock) RecordProcessed() {
 	m.TotalProcessedRecords++
 }
 
+// FileFailed adds an error which stopped the file from
+// being read any further and marks the file as failed
+func (m *Mock) FileFailed(err error) {
+	m.Errors = append(m.Errors, err)
+	m.Failed = true
+}
+
 // Completed records the ts of the entire process
 func (m *Mock) Completed() {
 	m.CompletedAt = time.Now().Format(time.RFC3339)
@@ -75,6 +83,13 @@ func (m *Mock) GetTotalFailedRecords() int {
 	return m.TotalFailedRecords
 }
 
+// Restore restores the record counts of a resumed transformation
+func (m *Mock) Restore(processed, transformed, failed int) {
+	m.TotalProcessedRecords = processed
+	m.TotalTransformedRecords = transformed
+	m.TotalFailedRecords = failed
+}
+
 // SetFilename sets the name of the file
 func (m *Mock) SetFilename(name string) {
 	m.FileName = name
@@ -94,3 +109,23 @@ func (m *Mock) GetHeaders() []string {
 func (m *Mock) SetHeaders(headers []string) {
 	m.Headers = headers
 }
+
+// GetTotalProcessedRecords returns the total processed records
+func (m *Mock) GetTotalProcessedRecords() int {
+	return m.TotalProcessedRecords
+}
+
+// HasFailed reports if the file failed to be read
+func (m *Mock) HasFailed() bool {
+	return m.Failed
+}
+
+// GetDuration returns the duration of the process for display
+func (m *Mock) GetDuration() string {
+	return m.DurationDisplay
+}
+
+// GetCompletedAt returns the ts the process completed at
+func (m *Mock) GetCompletedAt() string {
+	return m.CompletedAt
+}
@@ -4,12 +4,14 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"text/template"
 	"time"
 
-	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
+	"github.com/dele454/medium/csv-transform-to-html/internal/errs"
+	"github.com/dele454/medium/csv-transform-to-html/internal/templates"
 )
 
 // Reporter ops for any tranformation reporter
@@ -18,8 +20,10 @@ type Reporter interface {
 	RecordFailed()
 	RecordProcessed()
 	RecordTransformed()
+	FileFailed(err error)
 	Completed()
 	AddDuration(since float64)
+	Restore(processed, transformed, failed int)
 
 	WriteReportToStdOut(ctx context.Context) error
 
@@ -29,8 +33,12 @@ type Reporter interface {
 	GetHeaders() []string
 	GetFilename() string
 	GetErrors() []error
+	GetTotalProcessedRecords() int
 	GetTotalTransformedRecords() int
 	GetTotalFailedRecords() int
+	HasFailed() bool
+	GetDuration() string
+	GetCompletedAt() string
 }
 
 // TransformationReporter provides stats after the complete
@@ -41,12 +49,26 @@ type TransformationReporter struct {
 	TotalProcessedRecords   int
 	TotalTransformedRecords int
 	TotalFailedRecords      int
+	Failed                  bool
 	Errors                  []error
 	Duration                float64
 	DurationDisplay         string
 	CompletedAt             string
 }
 
+// funcs helpers available to the report template
+var funcs = template.FuncMap{
+	// record details the position of an error found in
+	// a record of the source file, if any
+	"record": func(err error) *errs.RecordError {
+		var re *errs.RecordError
+		if errors.As(err, &re) {
+			return re
+		}
+		return nil
+	},
+}
+
 // NewTransformationReporter create a new instance of a report
 func NewTransformationReporter() Reporter {
 	return &TransformationReporter{}
@@ -56,18 +78,16 @@ func NewTransformationReporter() Reporter {
 func (t *TransformationReporter) WriteReportToStdOut(ctx context.Context) error {
 	var err error
 
-	path := utils.RootDir()
-
 	// create template
-	tmpl, err := template.Must(template.New("STDOUT"), err).
-		ParseFiles(path + "/internal/transform/template/report.tmpl")
+	tmpl, err := template.Must(template.New("STDOUT").Funcs(funcs), err).
+		ParseFS(templates.FS(templates.Report), templates.Report)
 	if err != nil {
 		return err
 	}
 
 	// apply tmpl to data
 	var processed bytes.Buffer
-	err = tmpl.ExecuteTemplate(&processed, "report.tmpl", t)
+	err = tmpl.ExecuteTemplate(&processed, templates.Report, t)
 	if err != nil {
 		return err
 	}
@@ -108,6 +128,13 @@ func (t *TransformationReporter) RecordProcessed() {
 	t.TotalProcessedRecords++
 }
 
+// FileFailed adds an error which stopped the file from
+// being read any further and marks the file as failed
+func (t *TransformationReporter) FileFailed(err error) {
+	t.Errors = append(t.Errors, err)
+	t.Failed = true
+}
+
 // Completed records the ts of the entire process
 func (t *TransformationReporter) Completed() {
 	t.CompletedAt = time.Now().Format(time.RFC3339)
@@ -119,6 +146,13 @@ func (t *TransformationReporter) AddDuration(since float64) {
 	t.Duration += since
 }
 
+// Restore restores the record counts of a resumed transformation
+func (t *TransformationReporter) Restore(processed, transformed, failed int) {
+	t.TotalProcessedRecords = processed
+	t.TotalTransformedRecords = transformed
+	t.TotalFailedRecords = failed
+}
+
 // SetFilename sets the name of the file
 func (t *TransformationReporter) SetFilename(name string) {
 	t.FileName = name
@@ -153,3 +187,23 @@ func (t *TransformationReporter) GetTotalTransformedRecords() int {
 func (t *TransformationReporter) GetTotalFailedRecords() int {
 	return t.TotalFailedRecords
 }
+
+// GetTotalProcessedRecords returns the total processed records
+func (t *TransformationReporter) GetTotalProcessedRecords() int {
+	return t.TotalProcessedRecords
+}
+
+// HasFailed reports if the file failed to be read
+func (t *TransformationReporter) HasFailed() bool {
+	return t.Failed
+}
+
+// GetDuration returns the duration of the process for display
+func (t *TransformationReporter) GetDuration() string {
+	return t.DurationDisplay
+}
+
+// GetCompletedAt returns the ts the process completed at
+func (t *TransformationReporter) GetCompletedAt() string {
+	return t.CompletedAt
+}
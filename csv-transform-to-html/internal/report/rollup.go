@@ -0,0 +1,116 @@
+package report
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"text/template"
+	"time"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/templates"
+)
+
+// RollUp aggregates the reports of every file transformed
+// during a batch run
+type RollUp struct {
+	Reports         []Reporter
+	DurationDisplay string
+	CompletedAt     string
+	start           time.Time
+}
+
+// NewRollUp creates a new roll-up report, timing the run from now
+func NewRollUp() *RollUp {
+	return &RollUp{start: time.Now()}
+}
+
+// Add adds the report of a transformed file
+func (r *RollUp) Add(reporter Reporter) {
+	r.Reports = append(r.Reports, reporter)
+}
+
+// Completed records the ts & duration of the entire run
+func (r *RollUp) Completed() {
+	r.CompletedAt = time.Now().Format(time.RFC3339)
+	r.DurationDisplay = fmt.Sprintf("%.2f", time.Since(r.start).Seconds()) + "s"
+}
+
+// WriteReportToStdOut writes the roll-up report to stdout
+func (r *RollUp) WriteReportToStdOut(ctx context.Context) error {
+	var err error
+
+	// create template
+	tmpl, err := template.Must(template.New("ROLLUP"), err).
+		ParseFS(templates.FS(templates.RollUp), templates.RollUp)
+	if err != nil {
+		return err
+	}
+
+	// apply tmpl to data
+	w := bufio.NewWriter(os.Stdout)
+	err = tmpl.ExecuteTemplate(w, templates.RollUp, r)
+	if err != nil {
+		return err
+	}
+
+	// flush buffer
+	return w.Flush()
+}
+
+// GetTotalFiles returns the nos of files transformed
+func (r *RollUp) GetTotalFiles() int {
+	return len(r.Reports)
+}
+
+// GetTotalFailedFiles returns the nos of files which failed to be read
+func (r *RollUp) GetTotalFailedFiles() int {
+	return r.sum(func(reporter Reporter) int {
+		if reporter.HasFailed() {
+			return 1
+		}
+		return 0
+	})
+}
+
+// GetTotalProcessedRecords returns the total processed records of all files
+func (r *RollUp) GetTotalProcessedRecords() int {
+	return r.sum(Reporter.GetTotalProcessedRecords)
+}
+
+// GetTotalFailedRecords returns the total failed records of all files
+func (r *RollUp) GetTotalFailedRecords() int {
+	return r.sum(Reporter.GetTotalFailedRecords)
+}
+
+// GetTotalTransformedRecords returns the total transformed records of all files
+func (r *RollUp) GetTotalTransformedRecords() int {
+	return r.sum(Reporter.GetTotalTransformedRecords)
+}
+
+// GetTotalErrors returns the total errors of all files
+func (r *RollUp) GetTotalErrors() int {
+	return r.sum(func(reporter Reporter) int {
+		return len(reporter.GetErrors())
+	})
+}
+
+// GetDuration returns the duration of the run for display
+func (r *RollUp) GetDuration() string {
+	return r.DurationDisplay
+}
+
+// GetCompletedAt returns the ts the run completed at
+func (r *RollUp) GetCompletedAt() string {
+	return r.CompletedAt
+}
+
+// sum sums a stat of the reports of all files
+func (r *RollUp) sum(stat func(Reporter) int) int {
+	var total int
+	for _, reporter := range r.Reports {
+		total += stat(reporter)
+	}
+
+	return total
+}
@@ -0,0 +1,99 @@
+package rules
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/errs"
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
+	"github.com/shopspring/decimal"
+)
+
+// Tolerances largest differences allowed between the totals of a
+// record and the totals worked out from its other fields
+type Tolerances struct {
+	// Revenue between TotalRevenue and UnitsSold × UnitPrice
+	Revenue decimal.Decimal
+	// Cost between TotalCost and UnitsSold × UnitCost
+	Cost decimal.Decimal
+	// Profit between TotalProfit and TotalRevenue − TotalCost
+	Profit decimal.Decimal
+}
+
+// DefaultTolerances allow for totals rounded to the cent
+var DefaultTolerances = Tolerances{
+	Revenue: decimal.New(1, -2),
+	Cost:    decimal.New(1, -2),
+	Profit:  decimal.New(1, -2),
+}
+
+// Validate checks none of the tolerances is negative
+func (t Tolerances) Validate() error {
+	for _, d := range []decimal.Decimal{t.Revenue, t.Cost, t.Profit} {
+		if d.IsNegative() {
+			return fmt.Errorf(errs.ErrorInvalidTolerance.Error(), d)
+		}
+	}
+
+	return nil
+}
+
+// ParseTolerance parses a tolerance given as an amount, e.g 0.01
+func ParseTolerance(s string) (decimal.Decimal, error) {
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf(errs.ErrorInvalidTolerance.Error(), s)
+	}
+
+	return d, nil
+}
+
+// Check checks the relationships between the fields of a record
+// with the given tolerances, returning an error per rule broken.
+//
+// The errors are field errors of the field found at fault, so
+// they can be reported against its cell.
+func Check(sr utils.SalesRecord, t Tolerances) []error {
+	var violations []error
+
+	// dates are only compared when both are set
+	if !sr.OrderDate.IsZero() && !sr.ShipDate.IsZero() && sr.ShipDate.Before(sr.OrderDate) {
+		violations = append(violations, fieldError("ShipDate", utils.FormatDate(sr.ShipDate),
+			fmt.Errorf(errs.ErrorShipBeforeOrder.Error(), utils.FormatDate(sr.OrderDate))))
+	}
+
+	units := decimal.NewFromInt(sr.UnitsSold)
+
+	totals := []struct {
+		field     string
+		total     decimal.Decimal
+		expected  decimal.Decimal
+		formula   string
+		tolerance decimal.Decimal
+	}{
+		{"TotalRevenue", sr.TotalRevenue, units.Mul(sr.UnitPrice), "UnitsSold × UnitPrice", t.Revenue},
+		{"TotalCost", sr.TotalCost, units.Mul(sr.UnitCost), "UnitsSold × UnitCost", t.Cost},
+		{"TotalProfit", sr.TotalProfit, sr.TotalRevenue.Sub(sr.TotalCost), "TotalRevenue − TotalCost", t.Profit},
+	}
+
+	for _, r := range totals {
+		if r.total.Sub(r.expected).Abs().GreaterThan(r.tolerance) {
+			violations = append(violations, fieldError(r.field, utils.FormatAmount(r.total),
+				fmt.Errorf(errs.ErrorTotalMismatch.Error(), r.field, r.formula, utils.FormatAmount(r.expected))))
+		}
+	}
+
+	return violations
+}
+
+// fieldError wraps a broken rule with the details of the named field
+func fieldError(name, value string, err error) error {
+	f, _ := reflect.TypeOf(utils.SalesRecord{}).FieldByName(name)
+
+	return &errs.FieldError{
+		Index: f.Index[0],
+		Field: name,
+		Value: value,
+		Err:   err,
+	}
+}
@@ -0,0 +1,111 @@
+package rules
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/errs"
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
+	"github.com/shopspring/decimal"
+)
+
+// record returns a record consistent with every rule
+func record() utils.SalesRecord {
+	return utils.SalesRecord{
+		OrderDate:    time.Date(2010, 5, 28, 0, 0, 0, 0, time.UTC),
+		ShipDate:     time.Date(2010, 6, 27, 0, 0, 0, 0, time.UTC),
+		UnitsSold:    9925,
+		UnitPrice:    decimal.RequireFromString("255.28"),
+		UnitCost:     decimal.RequireFromString("159.42"),
+		TotalRevenue: decimal.RequireFromString("2533654.00"),
+		TotalCost:    decimal.RequireFromString("1582243.50"),
+		TotalProfit:  decimal.RequireFromString("951410.50"),
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(sr *utils.SalesRecord)
+		fields []string
+	}{
+		{name: "consistent", modify: func(sr *utils.SalesRecord) {}},
+		{name: "shipped same day", modify: func(sr *utils.SalesRecord) { sr.ShipDate = sr.OrderDate }},
+		{name: "shipped before ordered", modify: func(sr *utils.SalesRecord) { sr.ShipDate = sr.OrderDate.AddDate(0, 0, -1) }, fields: []string{"ShipDate"}},
+		{name: "revenue within tolerance", modify: func(sr *utils.SalesRecord) {
+			sr.TotalRevenue = decimal.RequireFromString("2533654.01")
+			sr.TotalProfit = decimal.RequireFromString("951410.51")
+		}},
+		{name: "revenue off", modify: func(sr *utils.SalesRecord) { sr.TotalRevenue = decimal.RequireFromString("2533654.02") }, fields: []string{"TotalRevenue", "TotalProfit"}},
+		{name: "cost off", modify: func(sr *utils.SalesRecord) { sr.UnitCost = decimal.RequireFromString("159.43") }, fields: []string{"TotalCost"}},
+		{name: "profit off", modify: func(sr *utils.SalesRecord) { sr.TotalProfit = decimal.RequireFromString("951401.50") }, fields: []string{"TotalProfit"}},
+	}
+
+	for _, test := range tests {
+		sr := record()
+		test.modify(&sr)
+
+		violations := Check(sr, DefaultTolerances)
+		if len(violations) != len(test.fields) {
+			t.Fatalf("%s: expected %d violations, got %v", test.name, len(test.fields), violations)
+		}
+
+		for i, v := range violations {
+			var fe *errs.FieldError
+			if !errors.As(v, &fe) || fe.Field != test.fields[i] {
+				t.Fatalf("%s: expected a violation of %s, got %v", test.name, test.fields[i], v)
+			}
+		}
+	}
+
+	// the index of the field is kept for its cell to be reported
+	sr := record()
+	sr.ShipDate = sr.OrderDate.AddDate(0, 0, -1)
+
+	var fe *errs.FieldError
+	if !errors.As(Check(sr, DefaultTolerances)[0], &fe) || fe.Index != 7 || fe.Value != "5/27/2010" {
+		t.Fatalf("Unexpected violation %+v", fe)
+	}
+
+	expected := "'ShipDate' Field must not be before the OrderDate 5/28/2010."
+	if fe.Error() != expected {
+		t.Fatalf("\nMessage Mismatch:\nExpected: %v\nGot: %v", expected, fe.Error())
+	}
+
+	// a wider tolerance accepts the difference
+	sr = record()
+	sr.TotalCost = decimal.RequireFromString("1582243.00")
+	sr.TotalProfit = decimal.RequireFromString("951411.00")
+
+	if violations := Check(sr, DefaultTolerances); len(violations) != 1 {
+		t.Fatalf("Expected the cost to be off, got %v", violations)
+	}
+
+	wide := Tolerances{Revenue: decimal.Zero, Cost: decimal.NewFromInt(1), Profit: decimal.Zero}
+	if violations := Check(sr, wide); len(violations) != 0 {
+		t.Fatalf("Expected no violations, got %v", violations)
+	}
+}
+
+func TestParseTolerance(t *testing.T) {
+	d, err := ParseTolerance("0.5")
+	if err != nil || !d.Equal(decimal.RequireFromString("0.5")) {
+		t.Fatalf("Expected 0.5, got %v, %v", d, err)
+	}
+
+	for _, s := range []string{"cent", ""} {
+		if _, err := ParseTolerance(s); err == nil {
+			t.Fatalf("Expected an error for tolerance '%s'", s)
+		}
+	}
+
+	if err := DefaultTolerances.Validate(); err != nil {
+		t.Fatalf("Expected the default tolerances to be valid, got %v", err)
+	}
+
+	negative, _ := ParseTolerance("-0.01")
+	if err := (Tolerances{Profit: negative}).Validate(); err == nil {
+		t.Fatal("Expected a negative tolerance to be refused")
+	}
+}
@@ -0,0 +1,62 @@
+package templates
+
+import (
+	"embed"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/errs"
+)
+
+// Names of the templates shipped with the binary
+const (
+	Output      = "output.tmpl"
+	Report      = "report.tmpl"
+	Index       = "index.tmpl"
+	Interactive = "interactive.tmpl"
+	Summary     = "summary.tmpl"
+	Charts      = "charts.tmpl"
+	Batch       = "batch.tmpl"
+	RollUp      = "rollup.tmpl"
+)
+
+//go:embed *.tmpl
+var defaults embed.FS
+
+// dir directory holding custom templates, if any
+var dir string
+
+// SetDir sets the directory to look up custom templates in
+//
+// A template found in the directory takes precedence over
+// the default one embedded in the binary.
+func SetDir(d string) error {
+	if d == "" {
+		dir = ""
+		return nil
+	}
+
+	f, err := os.Stat(d)
+	if err != nil {
+		return err
+	}
+
+	if !f.IsDir() {
+		return errs.ErrorTemplateDirNotDir
+	}
+
+	dir = d
+	return nil
+}
+
+// FS returns the file system the named template should be parsed from
+func FS(name string) fs.FS {
+	if dir != "" {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return os.DirFS(dir)
+		}
+	}
+
+	return defaults
+}
@@ -0,0 +1,52 @@
+package templates
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefaults(t *testing.T) {
+	if err := SetDir(""); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{Output, Report} {
+		if _, err := fs.ReadFile(FS(name), name); err != nil {
+			t.Fatalf("Template '%s' should be embedded: %v", name, err)
+		}
+	}
+}
+
+func TestCustomDir(t *testing.T) {
+	dir := t.TempDir()
+	defer SetDir("")
+
+	err := os.WriteFile(filepath.Join(dir, Report), []byte("custom"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetDir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := fs.ReadFile(FS(Report), Report)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != "custom" {
+		t.Fatal("Custom report template should take precedence")
+	}
+
+	// output template is not overridden so falls back to the default
+	if _, err := fs.ReadFile(FS(Output), Output); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetDir(filepath.Join(dir, Report)); err == nil {
+		t.Fatal("A file should not be accepted as templates directory")
+	}
+}
@@ -0,0 +1,70 @@
+package transform
+
+import (
+	"bufio"
+	"html/template"
+	"strings"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/report"
+	"github.com/dele454/medium/csv-transform-to-html/internal/templates"
+)
+
+// BatchIndexName name of the combined index page of a batch run
+const BatchIndexName = "index"
+
+// BatchIndex details of the combined index page of a batch run
+type BatchIndex struct {
+	Files  []BatchFile
+	Report *report.RollUp
+}
+
+// BatchFile a single file transformed during a batch run
+type BatchFile struct {
+	Link   string
+	Report report.Reporter
+}
+
+// WriteBatchIndex writes the combined index page of a batch run
+// linking to the output of every file along with its stats.
+//
+// The page is written as html whatever the output format.
+func WriteBatchIndex(format string, rollup *report.RollUp) error {
+	var err error
+
+	ext := strings.ToLower(format)
+	if ext == "" {
+		ext = FormatHTML
+	}
+
+	index := &BatchIndex{Report: rollup}
+	for _, r := range rollup.Reports {
+		index.Files = append(index.Files, BatchFile{
+			Link:   r.GetFilename() + "." + ext,
+			Report: r,
+		})
+	}
+
+	// create template
+	tmpl, err := template.Must(template.New("BATCH"), err).
+		ParseFS(templates.FS(templates.Batch), templates.Batch)
+	if err != nil {
+		return err
+	}
+
+	// create output html file
+	f, err := createOutputFile(BatchIndexName, FormatHTML)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// apply tmpl to data
+	w := bufio.NewWriter(f)
+	err = tmpl.ExecuteTemplate(w, templates.Batch, index)
+	if err != nil {
+		return err
+	}
+
+	// flush buffer
+	return w.Flush()
+}
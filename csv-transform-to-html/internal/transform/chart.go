@@ -0,0 +1,339 @@
+package transform
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
+)
+
+// dimensions of the rendered charts
+const (
+	chartWidth  = 520
+	chartHeight = 320
+	chartPad    = 40
+)
+
+// palette colours the bars, points & slices of the charts
+var palette = []string{"#337ab7", "#5cb85c", "#f0ad4e", "#d9534f", "#5bc0de", "#8e44ad", "#34495e", "#95a5a6"}
+
+// Charts collects the figures plotted above the html table and
+// renders them as inline svg so the document needs no scripts.
+type Charts struct {
+	regions  map[string]float64
+	months   map[string]float64
+	channels map[string]float64
+}
+
+// NewCharts creates an empty set of charts
+func NewCharts() *Charts {
+	return &Charts{
+		regions:  make(map[string]float64),
+		months:   make(map[string]float64),
+		channels: make(map[string]float64),
+	}
+}
+
+// Add adds a record to the figures of the charts
+//
+// Amounts are plotted as floats, the precision lost
+// being well below what a chart can show. Labels are
+// unescaped as the processor escapes them for HTML, to
+// be truncated and escaped once when rendered.
+func (c *Charts) Add(sr utils.SalesRecord) {
+	revenue := sr.TotalRevenue.InexactFloat64()
+	profit := sr.TotalProfit.InexactFloat64()
+
+	c.regions[html.UnescapeString(sr.Region)] += revenue
+	c.channels[html.UnescapeString(sr.SalesChannel)] += revenue
+
+	if !sr.OrderDate.IsZero() {
+		c.months[sr.OrderDate.Format("2006-01")] += profit
+	}
+}
+
+// Empty reports if no records were added to the charts
+func (c *Charts) Empty() bool {
+	return len(c.regions) == 0
+}
+
+// RevenueByRegion renders the revenue per region as a bar chart
+func (c *Charts) RevenueByRegion() template.HTML {
+	labels, values := sortedByValue(c.regions)
+	return barChart("Revenue by Region", labels, values)
+}
+
+// ProfitByMonth renders the profit per month of OrderDate as a line chart
+//
+// Months without any orders between the first & last month are
+// plotted with no profit to keep the time axis continuous.
+func (c *Charts) ProfitByMonth() template.HTML {
+	var (
+		labels []string
+		values []float64
+		first  time.Time
+		last   time.Time
+	)
+
+	for k := range c.months {
+		m, _ := time.Parse("2006-01", k)
+		if first.IsZero() || m.Before(first) {
+			first = m
+		}
+		if m.After(last) {
+			last = m
+		}
+	}
+
+	for m := first; !first.IsZero() && !m.After(last); m = m.AddDate(0, 1, 0) {
+		k := m.Format("2006-01")
+		labels = append(labels, k)
+		values = append(values, c.months[k])
+	}
+
+	return lineChart("Profit by Month", labels, values)
+}
+
+// ChannelShare renders the share of revenue per sales channel as a pie chart
+func (c *Charts) ChannelShare() template.HTML {
+	labels, values := sortedByValue(c.channels)
+	return pieChart("Revenue Share by Sales Channel", labels, values)
+}
+
+// sortedByValue lists the keys & values of a map, largest value first
+func sortedByValue(m map[string]float64) ([]string, []float64) {
+	labels := make([]string, 0, len(m))
+	for k := range m {
+		labels = append(labels, k)
+	}
+
+	sort.Slice(labels, func(i, j int) bool {
+		if m[labels[i]] == m[labels[j]] {
+			return labels[i] < labels[j]
+		}
+		return m[labels[i]] > m[labels[j]]
+	})
+
+	values := make([]float64, len(labels))
+	for i, k := range labels {
+		values[i] = m[k]
+	}
+
+	return labels, values
+}
+
+// svgOpen starts an svg document with a title
+func svgOpen(b *strings.Builder, title string) {
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`,
+		chartWidth, chartHeight, chartWidth, chartHeight)
+	fmt.Fprintf(b, `<text x="%d" y="18" text-anchor="middle" font-size="14" font-weight="bold">%s</text>`,
+		chartWidth/2, html.EscapeString(title))
+}
+
+// barChart renders a horizontal bar chart
+func barChart(title string, labels []string, values []float64) template.HTML {
+	var b strings.Builder
+
+	svgOpen(&b, title)
+
+	const labelWidth = 200
+	var (
+		top   = 30.0
+		plot  = float64(chartWidth - labelWidth - chartPad)
+		max   = maxOf(values)
+		slot  = (float64(chartHeight) - top - 10) / math.Max(float64(len(values)), 1)
+		barHt = math.Max(slot*0.7, 1)
+	)
+
+	for i, v := range values {
+		y := top + float64(i)*slot
+		w := 0.0
+		if max > 0 {
+			w = math.Max(v, 0) / max * plot
+		}
+
+		fmt.Fprintf(&b, `<text x="%d" y="%.1f" text-anchor="end" dominant-baseline="middle">%s</text>`,
+			labelWidth-6, y+barHt/2, html.EscapeString(truncate(labels[i], 32)))
+		fmt.Fprintf(&b, `<rect x="%d" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%s: %s</title></rect>`,
+			labelWidth, y, w, barHt, palette[0], html.EscapeString(labels[i]), formatAmount(v))
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" dominant-baseline="middle" fill="#555">%s</text>`,
+			float64(labelWidth)+w+4, y+barHt/2, abbreviate(v))
+	}
+
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// lineChart renders a line chart of values over ordered labels
+func lineChart(title string, labels []string, values []float64) template.HTML {
+	var b strings.Builder
+
+	svgOpen(&b, title)
+
+	var (
+		left   = 60.0
+		top    = 30.0
+		bottom = float64(chartHeight) - 40
+		right  = float64(chartWidth) - 20
+		min    = math.Min(minOf(values), 0)
+		max    = maxOf(values)
+	)
+
+	if max == min {
+		max = min + 1
+	}
+
+	x := func(i int) float64 {
+		if len(values) < 2 {
+			return (left + right) / 2
+		}
+		return left + float64(i)*(right-left)/float64(len(values)-1)
+	}
+	y := func(v float64) float64 {
+		return bottom - (v-min)/(max-min)*(bottom-top)
+	}
+
+	// axes with the min & max values
+	fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#999"/>`, left, top, left, bottom)
+	fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#999"/>`, left, y(0), right, y(0))
+	fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="end" dominant-baseline="middle">%s</text>`, left-4, y(max), abbreviate(max))
+	fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="end" dominant-baseline="middle">%s</text>`, left-4, y(min), abbreviate(min))
+
+	// label at most 8 ticks of the x axis
+	step := int(math.Ceil(float64(len(labels)) / 8))
+	if step < 1 {
+		step = 1
+	}
+
+	points := make([]string, len(values))
+	for i, v := range values {
+		points[i] = fmt.Sprintf("%.1f,%.1f", x(i), y(v))
+
+		if i%step == 0 {
+			fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="middle" fill="#555">%s</text>`,
+				x(i), bottom+16, html.EscapeString(labels[i]))
+		}
+	}
+
+	fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`,
+		strings.Join(points, " "), palette[1])
+
+	for i, v := range values {
+		fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="3" fill="%s"><title>%s: %s</title></circle>`,
+			x(i), y(v), palette[1], html.EscapeString(labels[i]), formatAmount(v))
+	}
+
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// pieChart renders the share of each value of the total as a pie chart
+func pieChart(title string, labels []string, values []float64) template.HTML {
+	var b strings.Builder
+
+	svgOpen(&b, title)
+
+	var (
+		cx, cy = 150.0, 175.0
+		r      = 120.0
+		total  float64
+	)
+
+	for _, v := range values {
+		total += math.Max(v, 0)
+	}
+
+	angle := -math.Pi / 2
+	for i, v := range values {
+		share := 0.0
+		if total > 0 {
+			share = math.Max(v, 0) / total
+		}
+
+		colour := palette[i%len(palette)]
+		tip := fmt.Sprintf("<title>%s: %s (%.1f%%)</title>", html.EscapeString(labels[i]), formatAmount(v), share*100)
+
+		switch {
+		case share >= 1:
+			fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="%s">%s</circle>`, cx, cy, r, colour, tip)
+		case share > 0:
+			end := angle + share*2*math.Pi
+			large := 0
+			if share > 0.5 {
+				large = 1
+			}
+
+			fmt.Fprintf(&b, `<path d="M%.1f,%.1f L%.1f,%.1f A%.1f,%.1f 0 %d 1 %.1f,%.1f Z" fill="%s" stroke="#fff">%s</path>`,
+				cx, cy, cx+r*math.Cos(angle), cy+r*math.Sin(angle), r, r, large,
+				cx+r*math.Cos(end), cy+r*math.Sin(end), colour, tip)
+
+			angle = end
+		}
+
+		// legend
+		ly := 60.0 + float64(i)*20
+		fmt.Fprintf(&b, `<rect x="300" y="%.1f" width="12" height="12" fill="%s"/>`, ly, colour)
+		fmt.Fprintf(&b, `<text x="318" y="%.1f" dominant-baseline="middle">%s (%.1f%%)</text>`,
+			ly+6, html.EscapeString(truncate(labels[i], 24)), share*100)
+	}
+
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+func maxOf(values []float64) float64 {
+	max := 0.0
+	for i, v := range values {
+		if i == 0 || v > max {
+			max = v
+		}
+	}
+	return max
+}
+
+func minOf(values []float64) float64 {
+	min := 0.0
+	for i, v := range values {
+		if i == 0 || v < min {
+			min = v
+		}
+	}
+	return min
+}
+
+// abbreviate formats a value for an axis, e.g 1.2M
+func abbreviate(v float64) string {
+	a := math.Abs(v)
+
+	switch {
+	case a >= 1e9:
+		return fmt.Sprintf("%.1fB", v/1e9)
+	case a >= 1e6:
+		return fmt.Sprintf("%.1fM", v/1e6)
+	case a >= 1e3:
+		return fmt.Sprintf("%.1fK", v/1e3)
+	default:
+		return fmt.Sprintf("%.0f", v)
+	}
+}
+
+// formatAmount formats an amount with 2 decimal places
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// truncate shortens a label to at most n runes
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+
+	return string(r[:n-1]) + "…"
+}
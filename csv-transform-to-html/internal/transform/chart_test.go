@@ -0,0 +1,86 @@
+package transform
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/report"
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
+)
+
+func TestCharts(t *testing.T) {
+	c := NewCharts()
+	if !c.Empty() {
+		t.Fatal("Charts should be empty")
+	}
+
+	c.Add(utils.SalesRecord{Region: "Europe", SalesChannel: "Online", OrderDate: date("1/15/2012"), TotalRevenue: amount("100.00"), TotalProfit: amount("40.00")})
+	c.Add(utils.SalesRecord{Region: "Asia &amp; Pacific", SalesChannel: "Online", OrderDate: date("4/2/2012"), TotalRevenue: amount("300.00"), TotalProfit: amount("60.00")})
+	c.Add(utils.SalesRecord{Region: "Australia and Oceania, Fiji &amp; Samoa", SalesChannel: "Online", OrderDate: date("4/9/2012"), TotalRevenue: amount("50.00"), TotalProfit: amount("10.00")})
+
+	bar := string(c.RevenueByRegion())
+	if !strings.HasPrefix(bar, "<svg") || strings.Count(bar, "<rect") != 3 {
+		t.Fatalf("Expected a bar per region, got %s", bar)
+	}
+
+	// labels escaped by the processor are escaped once
+	if !strings.Contains(bar, "Asia &amp; Pacific") || strings.Contains(bar, "&amp;amp;") {
+		t.Fatal("Region labels should be escaped once")
+	}
+
+	// labels are truncated before being escaped, not to cut an entity
+	if !strings.Contains(bar, ">Australia and Oceania, Fiji &amp; S…</text>") ||
+		!strings.Contains(bar, "<title>Australia and Oceania, Fiji &amp; Samoa: 50.00</title>") {
+		t.Fatalf("Long region labels should be truncated whole, got %s", bar)
+	}
+
+	// Jan through to Apr with no orders in Feb & Mar
+	line := string(c.ProfitByMonth())
+	count := strings.Count(line, "<circle")
+	expected := 4
+	if count != expected {
+		t.Fatalf("Expected %d, got %d", expected, count)
+	}
+
+	if !strings.Contains(line, "2012-02: 0.00") {
+		t.Fatal("Months without orders should be plotted with no profit")
+	}
+
+	// a single channel takes up the whole pie
+	pie := string(c.ChannelShare())
+	if !strings.Contains(pie, "<circle") || !strings.Contains(pie, "Online (100.0%)") {
+		t.Fatalf("Expected a full pie for a single channel, got %s", pie)
+	}
+}
+
+func TestChartsWriteOutputToFile(t *testing.T) {
+	reporter := report.NewMockReporter()
+	transformer := NewHTMLTransformer(reporter, Options{Charts: true})
+
+	err := transformer.WriteOutputToFile(&Output{
+		FileName:     "charts_output.csv",
+		TotalHeaders: len(utils.GetHeaders()),
+		Headers:      utils.GetHeaders(),
+		Data: []utils.SalesRecord{
+			{Region: "Europe", SalesChannel: "Online", OrderDate: date("1/15/2012"), TotalRevenue: amount("100.00"), TotalProfit: amount("40.00")},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(utils.OutputDir() + "/charts_output.csv.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := string(b)
+	if strings.Count(doc, "<svg") != 3 {
+		t.Fatal("Output should hold 3 charts")
+	}
+
+	if strings.Index(doc, "<svg") > strings.Index(doc, "<td>Europe</td>") {
+		t.Fatal("Charts should sit above the table")
+	}
+}
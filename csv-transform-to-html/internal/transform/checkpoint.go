@@ -0,0 +1,121 @@
+package transform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/checkpoint"
+	"github.com/dele454/medium/csv-transform-to-html/internal/errs"
+	"github.com/dele454/medium/csv-transform-to-html/internal/report"
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
+)
+
+// Resumable reports why the transformation of the named source file
+// into the given format cannot be resumed, if so.
+//
+// Only an html output written as a single document is streamed row
+// by row, so can be appended to where it was left off.
+func Resumable(name, format string, opts Options) error {
+	f := strings.ToLower(format)
+	if (f != FormatHTML && f != "") || opts.PageSize > 0 || opts.Summary || opts.Charts {
+		return fmt.Errorf(errs.ErrorResumeUnsupported.Error(), name,
+			"only html output written as a single document without summary or charts can be appended to")
+	}
+
+	return nil
+}
+
+// checkpointer persists the progress of a transformation every
+// given nos of rows.
+//
+// The output and rejected file are flushed before each checkpoint,
+// so a checkpoint never runs ahead of what was written.
+type checkpointer struct {
+	reporter report.Reporter
+	state    *checkpoint.Checkpoint
+	every    int
+	// flush flushes the output, returning its size
+	flush func() (int64, error)
+}
+
+// newCheckpointer creates a checkpointer for the options, nil
+// if the transformation isn't checkpointed
+func newCheckpointer(reporter report.Reporter, opts Options, flush func() (int64, error)) *checkpointer {
+	if opts.Checkpoint == nil {
+		return nil
+	}
+
+	return &checkpointer{
+		reporter: reporter,
+		state:    opts.Checkpoint,
+		every:    opts.CheckpointEvery,
+		flush:    flush,
+	}
+}
+
+// resuming reports if rows were handled before the transformation
+// was interrupted
+func (c *checkpointer) resuming() bool {
+	return c != nil && c.state.Rows > 0
+}
+
+// advance records a row handled, either transformed or failed,
+// taking a checkpoint every so often.
+func (c *checkpointer) advance(row utils.Row, transformed bool, q *quarantine) error {
+	if c == nil || c.every <= 0 {
+		return nil
+	}
+
+	// reading can no longer resume past the row, the
+	// last checkpoint is kept to resume from instead
+	if row.Offset == 0 {
+		c.every = 0
+		return nil
+	}
+
+	// rows the parser failed to read weren't processed
+	switch {
+	case row.Err != nil:
+		c.state.Failed++
+	case transformed:
+		c.state.Processed++
+		c.state.Transformed++
+	default:
+		c.state.Processed++
+		c.state.Failed++
+	}
+
+	c.state.Rows++
+	c.state.Offset = row.Offset
+	c.state.Line = row.EndLine
+
+	if c.state.Rows%c.every != 0 {
+		return nil
+	}
+
+	return c.save(q)
+}
+
+// save flushes the output & rejected file and persists the checkpoint
+func (c *checkpointer) save(q *quarantine) error {
+	var err error
+
+	if c.state.Output, err = c.flush(); err != nil {
+		return err
+	}
+
+	if c.state.Rejected, err = q.Flush(); err != nil {
+		return err
+	}
+
+	return c.state.Save(c.reporter.GetFilename())
+}
+
+// remove removes the checkpoint once the transformation completes
+func (c *checkpointer) remove() error {
+	if c == nil {
+		return nil
+	}
+
+	return checkpoint.Remove(c.reporter.GetFilename())
+}
@@ -2,179 +2,548 @@ package transform
 
 import (
 	"bufio"
-	"bytes"
-	"context"
 	"fmt"
 	"html/template"
+	"io"
 	"os"
 	"path/filepath"
 	"sync"
 	"time"
 
-	"github.com/dele454/medium/csv-transform-to-html/internal/errs"
 	"github.com/dele454/medium/csv-transform-to-html/internal/report"
+	"github.com/dele454/medium/csv-transform-to-html/internal/templates"
 	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
 )
 
-// Output details of the transformation sent to an io.Writer
-type Output struct {
-	FileName     string
-	FileLocation string
-	TotalHeaders int
-	Headers      []string
-	Data         []utils.SalesRecord
-}
-
-// Transformer ops every transformer should conform to
-type Transformer interface {
-	WriteOutputToFile(output *Output) error
-	ProcessRecord(wg *sync.WaitGroup, record <-chan []string, done <-chan bool)
-}
-
 // HTMLTransformer handles the processing of customer data
 // with the aid of preprocessors and writing the output
 // as an HTML document.
+//
+// Rows are streamed to the document as they come off the
+// pipeline so memory stays flat regardless of the input size.
 type HTMLTransformer struct {
 	processor utils.PreProcessor
 	reporter  report.Reporter
+	options   Options
 }
 
-// XMLTransformer handles the processing of customer data
-// with the aid of preprocessors and writing the output
-// as an XML document.
-type XMLTransformer struct{}
+// Index details of a paginated html output
+type Index struct {
+	FileName string
+	PageSize int
+	Pages    []Page
+	Summary  *Summary
+	Charts   *Charts
+	Report   report.Reporter
+}
+
+// Page a single page of a paginated html output
+type Page struct {
+	Number   int
+	FileName string
+	FirstRow int
+	LastRow  int
+}
+
+// funcs helpers available to the output templates
+var funcs = template.FuncMap{
+	// date formats a date the way the source file lays it out
+	"date": utils.FormatDate,
+	// amount formats an amount with the decimal places it was read with
+	"amount": utils.FormatAmount,
+}
 
 // HTMLTransformer creates a new instance of a transformer
 //
-// Accepts a reporter for reporting purposes.
-func NewHTMLTransformer(reporter report.Reporter) Transformer {
+// Accepts a reporter for reporting purposes and options
+// for paginating the output.
+func NewHTMLTransformer(reporter report.Reporter, opts Options) Transformer {
 	return &HTMLTransformer{
 		processor: utils.NewProcessor(),
 		reporter:  reporter,
+		options:   opts,
 	}
 }
 
 // ProcessRecord process records received via the chan
-func (tr *HTMLTransformer) ProcessRecord(wg *sync.WaitGroup, record <-chan []string, done <-chan bool) {
+func (tr *HTMLTransformer) ProcessRecord(wg *sync.WaitGroup, record <-chan utils.Row, done <-chan bool) {
 	var (
-		now  = time.Now()
-		data []utils.SalesRecord
-		end  bool
-		ctx  = context.Background()
+		now    = time.Now()
+		out    *htmlOutput
+		failed bool
 	)
 
-	defer func() {
-		tr.reporter.SetFilename(filepath.Base(tr.reporter.GetFilename()))
-		tr.reporter.AddDuration(time.Since(now).Seconds())
-		tr.reporter.Completed()
+	defer wg.Done()
 
-		err := tr.reporter.WriteReportToStdOut(ctx)
-		if err != nil {
-			utils.Log(utils.ColorError, err)
+	// the output is flushed before each checkpoint
+	cp := newCheckpointer(tr.reporter, tr.options, func() (int64, error) {
+		if out == nil {
+			return tr.options.Checkpoint.Output, nil
 		}
-
-		wg.Done()
-	}()
+		return out.Flush()
+	})
 
 	// process pipeline
-	for {
-		select {
-		case <-done:
-			// reading has completed.
-			end = true
-		case row := <-record:
-			// read from pipeline
-			if len(row) == 0 {
-				tr.reporter.RecordFailed()
-				tr.reporter.AddError(errs.ErrorEmptyRowFound)
-
-				utils.Log(utils.ColorError, errs.ErrorEmptyRowFound)
-				continue
-			}
-
-			// unmarshal records
-			var sr utils.SalesRecord
-			sr, err := tr.processor.Unmarshal(row, sr)
-			if err != nil {
-				tr.reporter.RecordFailed()
-				tr.reporter.AddError(err)
-
-				continue
-			}
-
-			// push row to collection
-			tr.reporter.RecordTransformed()
-			data = append(data, sr)
+	consume(tr.processor, tr.reporter, tr.options.Tolerances, record, done, cp, func(sr utils.SalesRecord) {
+		if failed {
+			return
 		}
 
-		// means parser has signaled end of file
-		// exit loop
-		if end {
-			break
+		// the output is created lazily as the parser
+		// sets the file's name & headers once it starts reading
+		if out == nil {
+			out = newHTMLOutput(tr.output(), tr.options)
 		}
-	}
 
-	// send output to file
-	err := tr.WriteOutputToFile(&Output{
-		FileName:     filepath.Base(tr.reporter.GetFilename()),
-		TotalHeaders: len(tr.reporter.GetHeaders()),
-		Headers:      tr.reporter.GetHeaders(),
-		Data:         data,
+		// stream row to file
+		if err := out.Write(sr); err != nil {
+			tr.reporter.AddError(err)
+			failed = true
+		}
 	})
-	if err != nil {
+
+	if out == nil {
+		out = newHTMLOutput(tr.output(), tr.options)
+	}
+
+	if err := out.Close(); err != nil {
+		tr.reporter.AddError(err)
+	} else if !failed {
+		// nothing is left to resume
+		if err := cp.remove(); err != nil {
+			tr.reporter.AddError(err)
+		}
+	}
+
+	// the index page holds the summary of the run
+	// so is written once the transformation completes
+	finish(tr.reporter, now)
+
+	if err := out.WriteIndex(tr.reporter); err != nil {
 		tr.reporter.AddError(err)
 	}
+
+	writeReport(tr.reporter)
 }
 
 // WriteOutputToFile write output data to file.
 func (tr *HTMLTransformer) WriteOutputToFile(output *Output) error {
+	out := newHTMLOutput(output, tr.options)
+
+	for _, sr := range output.Data {
+		if err := out.Write(sr); err != nil {
+			out.Close()
+			return err
+		}
+	}
+
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	return out.WriteIndex(tr.reporter)
+}
+
+// output describes the document for the file being transformed
+func (tr *HTMLTransformer) output() *Output {
+	return &Output{
+		FileName:     filepath.Base(tr.reporter.GetFilename()),
+		TotalHeaders: len(tr.reporter.GetHeaders()),
+		Headers:      tr.reporter.GetHeaders(),
+	}
+}
+
+// htmlOutput writes records either as a single html document
+// or split into pages of a fixed nos of rows.
+type htmlOutput struct {
+	output      *Output
+	pageSize    int
+	interactive bool
+	summary     *Summary
+	charts      *Charts
+	tmpl        *template.Template
+	writer      *htmlWriter
+	pages       []Page
+	total       int
+	// resumeAt size of the document of an interrupted
+	// transformation to append to, if any
+	resumeAt int64
+}
+
+// newHTMLOutput creates an output for the given document
+//
+// A page size of zero writes all records to a single document.
+func newHTMLOutput(output *Output, opts Options) *htmlOutput {
+	o := &htmlOutput{
+		output:      output,
+		pageSize:    opts.PageSize,
+		interactive: opts.Interactive,
+	}
+
+	if opts.Summary {
+		o.summary = NewSummary()
+	}
+
+	if opts.Charts {
+		o.charts = NewCharts()
+	}
+
+	// rows are appended to the document the
+	// interrupted transformation left off
+	if cp := opts.Checkpoint; cp != nil && cp.Rows > 0 {
+		o.resumeAt = cp.Output
+		o.total = cp.Transformed
+	}
+
+	return o
+}
+
+// paginated reports if the output is split into pages
+func (o *htmlOutput) paginated() bool {
+	return o.pageSize > 0
+}
+
+// pageName name of the nth page of the output
+func (o *htmlOutput) pageName(n int) string {
+	return fmt.Sprintf("%s-page-%d", o.output.FileName, n)
+}
+
+// Write writes a record to the current page, moving on to
+// a new page once the current one is full.
+func (o *htmlOutput) Write(sr utils.SalesRecord) error {
+	if o.writer != nil && o.paginated() && o.writer.output.TotalRecords == o.pageSize {
+		o.writer.output.Next = o.pageName(len(o.pages)+1) + ".html"
+
+		if err := o.closePage(); err != nil {
+			return err
+		}
+	}
+
+	if o.writer == nil {
+		if err := o.openPage(); err != nil {
+			return err
+		}
+	}
+
+	o.total++
+	if o.summary != nil {
+		o.summary.Add(sr)
+	}
+
+	if o.charts != nil {
+		o.charts.Add(sr)
+	}
+
+	return o.writer.Write(sr)
+}
+
+// Close closes the current page
+//
+// A single document is always written, even without any records.
+func (o *htmlOutput) Close() error {
+	if o.writer == nil && !o.paginated() {
+		if err := o.openPage(); err != nil {
+			return err
+		}
+	}
+
+	if o.writer == nil {
+		return nil
+	}
+
+	return o.closePage()
+}
+
+// Flush flushes the current document, returning its size
+func (o *htmlOutput) Flush() (int64, error) {
+	if o.writer == nil {
+		return o.resumeAt, nil
+	}
+
+	return o.writer.Flush()
+}
+
+// WriteIndex writes the index page linking to all pages of
+// a paginated output along with the summary of the run.
+func (o *htmlOutput) WriteIndex(reporter report.Reporter) error {
 	var err error
 
-	path := utils.RootDir()
+	if !o.paginated() {
+		return nil
+	}
 
 	// create template
-	tmpl, err := template.Must(template.New("HTML"), err).ParseFiles(path + "/internal/transform/template/output.tmpl")
+	tmpl, err := template.Must(template.New("INDEX"), err).
+		ParseFS(templates.FS(templates.Index), templates.Index)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range []string{templates.Summary, templates.Charts} {
+		tmpl, err = tmpl.ParseFS(templates.FS(name), name)
+		if err != nil {
+			return err
+		}
+	}
+
+	// create output html file
+	f, err := createOutputFile(o.output.FileName, "html")
 	if err != nil {
-		tr.reporter.AddError(err)
 		return err
 	}
+	defer f.Close()
 
 	// apply tmpl to data
-	var processed bytes.Buffer
-	err = tmpl.ExecuteTemplate(&processed, "output.tmpl", output)
+	w := bufio.NewWriter(f)
+	err = tmpl.ExecuteTemplate(w, templates.Index, &Index{
+		FileName: o.output.FileName,
+		PageSize: o.pageSize,
+		Pages:    o.pages,
+		Summary:  o.summary,
+		Charts:   o.charts,
+		Report:   reporter,
+	})
 	if err != nil {
 		return err
 	}
 
-	// create output folder if not exists
-	folder := path + "/output"
+	// flush buffer
+	return w.Flush()
+}
 
-	if _, err := os.Stat(folder); os.IsNotExist(err) {
-		err := os.Mkdir(folder, os.ModePerm)
+// openPage opens the next document of the output
+func (o *htmlOutput) openPage() error {
+	var err error
+
+	// create template
+	if o.tmpl == nil {
+		o.tmpl, err = parseOutputTemplate(o.interactive)
 		if err != nil {
-			panic(errs.ErrorFailedToCreateDirectory)
+			return err
 		}
 	}
 
-	// create output html file
-	f, err := os.Create(fmt.Sprintf("%s/%s.html", folder, output.FileName))
+	output := *o.output
+	output.Data = nil
+	name := output.FileName
+
+	// a single document carries the summary & charts whereas
+	// a paginated output carries them on its index
+	if !o.paginated() {
+		output.Summary = o.summary
+		output.Charts = o.charts
+	}
+
+	if o.paginated() {
+		output.Page = len(o.pages) + 1
+		output.Index = o.output.FileName + ".html"
+		if output.Page > 1 {
+			output.Prev = o.pageName(output.Page-1) + ".html"
+		}
+
+		name = o.pageName(output.Page)
+		o.pages = append(o.pages, Page{
+			Number:   output.Page,
+			FileName: name + ".html",
+			FirstRow: o.total + 1,
+		})
+	}
+
+	if o.resumeAt > 0 && !o.paginated() {
+		output.TotalRecords = o.total
+		o.writer, err = appendHTMLWriter(name, o.tmpl, &output, o.resumeAt)
+		return err
+	}
+
+	o.writer, err = newHTMLWriter(name, o.tmpl, &output)
+	return err
+}
+
+// closePage closes the current document of the output
+func (o *htmlOutput) closePage() error {
+	if o.paginated() {
+		o.pages[len(o.pages)-1].LastRow = o.total
+	}
+
+	err := o.writer.Close()
+	o.writer = nil
+
+	return err
+}
+
+// htmlWriter streams an HTML document to file
+//
+// The header of the document is written on creation, each record
+// is appended as a table row and the footer is written on close.
+//
+// Charts sit above the table yet can only be drawn once all records
+// are known, so rows of a document with charts are spooled to a
+// temporary file and the document is assembled on close instead.
+type htmlWriter struct {
+	name   string
+	file   *os.File
+	w      *bufio.Writer
+	tmpl   *template.Template
+	output *Output
+	spool  bool
+}
+
+// parseOutputTemplate parses the templates of an html document
+//
+// The interactive template overrides the header & footer of the
+// output template with a self-contained page, inlining the styles
+// and scripts for sorting, filtering and searching the table.
+func parseOutputTemplate(interactive bool) (*template.Template, error) {
+	var err error
+
+	tmpl, err := template.Must(template.New("HTML").Funcs(funcs), err).
+		ParseFS(templates.FS(templates.Output), templates.Output)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	defer f.Close()
 
-	// write output to html file
-	w := bufio.NewWriter(f)
-	_, err = w.WriteString(processed.String())
+	for _, name := range []string{templates.Summary, templates.Charts} {
+		tmpl, err = tmpl.ParseFS(templates.FS(name), name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !interactive {
+		return tmpl, nil
+	}
+
+	return tmpl.ParseFS(templates.FS(templates.Interactive), templates.Interactive)
+}
+
+// newHTMLWriter creates the named output html file and writes
+// the header of the document.
+func newHTMLWriter(name string, tmpl *template.Template, output *Output) (*htmlWriter, error) {
+	var (
+		f   *os.File
+		err error
+	)
+
+	spool := output.Charts != nil
+
+	// create output html file or the spool for its rows
+	if spool {
+		f, err = os.CreateTemp("", "*.rows.html")
+	} else {
+		f, err = createOutputFile(name, "html")
+	}
 	if err != nil {
+		return nil, err
+	}
+
+	hw := &htmlWriter{
+		name:   name,
+		file:   f,
+		w:      bufio.NewWriter(f),
+		tmpl:   tmpl,
+		output: output,
+		spool:  spool,
+	}
+
+	if spool {
+		return hw, nil
+	}
+
+	if err = hw.tmpl.ExecuteTemplate(hw.w, "header", output); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return hw, nil
+}
+
+// appendHTMLWriter reopens the named output html file of an
+// interrupted transformation, truncated to the given size, to
+// append rows to past those already written.
+func appendHTMLWriter(name string, tmpl *template.Template, output *Output, size int64) (*htmlWriter, error) {
+	f, err := appendOutputFile(name, "html", size)
+	if err != nil {
+		return nil, err
+	}
+
+	return &htmlWriter{
+		name:   name,
+		file:   f,
+		w:      bufio.NewWriter(f),
+		tmpl:   tmpl,
+		output: output,
+	}, nil
+}
+
+// Write writes a record as a row of the table
+func (hw *htmlWriter) Write(sr utils.SalesRecord) error {
+	hw.output.TotalRecords++
+
+	return hw.tmpl.ExecuteTemplate(hw.w, "row", sr)
+}
+
+// Flush flushes the buffer, returning the size of the file
+func (hw *htmlWriter) Flush() (int64, error) {
+	if err := hw.w.Flush(); err != nil {
+		return 0, err
+	}
+
+	return hw.file.Seek(0, io.SeekCurrent)
+}
+
+// Close writes the footer of the document, flushes the buffer
+// and closes the file.
+func (hw *htmlWriter) Close() error {
+	if hw.spool {
+		return hw.assemble()
+	}
+
+	defer hw.file.Close()
+
+	if err := hw.tmpl.ExecuteTemplate(hw.w, "footer", hw.output); err != nil {
 		return err
 	}
 
 	// flush buffer
-	err = w.Flush()
+	return hw.w.Flush()
+}
+
+// assemble writes the header, the spooled rows and the footer
+// of the document to the output html file.
+func (hw *htmlWriter) assemble() error {
+	defer func() {
+		hw.file.Close()
+		os.Remove(hw.file.Name())
+	}()
+
+	if err := hw.w.Flush(); err != nil {
+		return err
+	}
+
+	if _, err := hw.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	// create output html file
+	f, err := createOutputFile(hw.name, "html")
 	if err != nil {
 		return err
 	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	if err = hw.tmpl.ExecuteTemplate(w, "header", hw.output); err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(w, hw.file); err != nil {
+		return err
+	}
+
+	if err = hw.tmpl.ExecuteTemplate(w, "footer", hw.output); err != nil {
+		return err
+	}
 
-	return nil
+	// flush buffer
+	return w.Flush()
 }
@@ -1,6 +1,9 @@
 package transform
 
 import (
+	"fmt"
+	"os"
+	"strings"
 	"sync"
 	"testing"
 
@@ -11,17 +14,17 @@ import (
 
 func TestProcessRecord(t *testing.T) {
 	reporter := report.NewMockReporter()
-	transformer := NewHTMLTransformer(reporter)
+	transformer := NewHTMLTransformer(reporter, Options{})
 
 	path := utils.RootDir()
-	p := parser.NewCSVParser(path+"/internal/testdata/100_sales_records.csv", reporter)
+	p := parser.NewCSVParser(path+"/internal/testdata/100_sales_records.csv", reporter, parser.Dialect{})
 
 	// create waitgroup
 	wg := new(sync.WaitGroup)
 	wg.Add(2)
 
 	// channels for pipeline
-	record := make(chan []string)
+	record := make(chan utils.Row)
 	done := make(chan bool)
 
 	go transformer.ProcessRecord(wg, record, done)
@@ -44,17 +47,17 @@ func TestProcessRecord(t *testing.T) {
 
 func TestProcessRecordFails(t *testing.T) {
 	reporter := report.NewMockReporter()
-	transformer := NewHTMLTransformer(reporter)
+	transformer := NewHTMLTransformer(reporter, Options{})
 
 	path := utils.RootDir()
-	p := parser.NewCSVParser(path+"/internal/testdata/fail_process_record.csv", reporter)
+	p := parser.NewCSVParser(path+"/internal/testdata/fail_process_record.csv", reporter, parser.Dialect{})
 
 	// create waitgroup
 	wg := new(sync.WaitGroup)
 	wg.Add(2)
 
 	// channels for pipeline
-	record := make(chan []string)
+	record := make(chan utils.Row)
 	done := make(chan bool)
 
 	go transformer.ProcessRecord(wg, record, done)
@@ -74,3 +77,141 @@ func TestProcessRecordFails(t *testing.T) {
 		t.Fatalf("Expected > %d, got %d", expected, count)
 	}
 }
+
+func TestWriteOutputToFile(t *testing.T) {
+	reporter := report.NewMockReporter()
+	transformer := NewHTMLTransformer(reporter, Options{})
+
+	output := &Output{
+		FileName:     "write_output.csv",
+		TotalHeaders: len(utils.GetHeaders()),
+		Headers:      utils.GetHeaders(),
+	}
+
+	err := transformer.WriteOutputToFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(utils.OutputDir() + "/write_output.csv.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(b), "No data was exported.") {
+		t.Fatal("Empty output should state no data was exported")
+	}
+
+	output.Data = []utils.SalesRecord{{Country: "Tuvalu"}, {Country: "Grenada"}}
+	err = transformer.WriteOutputToFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err = os.ReadFile(utils.OutputDir() + "/write_output.csv.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count := strings.Count(string(b), "<td>Tuvalu</td>") + strings.Count(string(b), "<td>Grenada</td>")
+	expected := 2
+	if count != expected {
+		t.Fatalf("Expected %d, got %d", expected, count)
+	}
+
+	if strings.Contains(string(b), "No data was exported.") {
+		t.Fatal("Output with data should not state no data was exported")
+	}
+}
+
+func TestPaginatedProcessRecord(t *testing.T) {
+	reporter := report.NewMockReporter()
+	transformer := NewHTMLTransformer(reporter, Options{PageSize: 30})
+
+	path := utils.RootDir()
+	p := parser.NewCSVParser(path+"/internal/testdata/100_sales_records.csv", reporter, parser.Dialect{})
+
+	// create waitgroup
+	wg := new(sync.WaitGroup)
+	wg.Add(2)
+
+	// channels for pipeline
+	record := make(chan utils.Row)
+	done := make(chan bool)
+
+	go transformer.ProcessRecord(wg, record, done)
+	go p.Read(wg, record, done)
+
+	wg.Wait()
+
+	count := len(reporter.GetErrors())
+	expected := 0
+	if count != expected {
+		t.Fatalf("Expected %d, got %d", expected, count)
+	}
+
+	index, err := os.ReadFile(utils.OutputDir() + "/100_sales_records.csv.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 100 rows over pages of 30 rows
+	pages := []struct {
+		rows  int
+		label string
+	}{{30, "1 - 30"}, {30, "31 - 60"}, {30, "61 - 90"}, {10, "91 - 100"}}
+
+	for i, page := range pages {
+		name := fmt.Sprintf("100_sales_records.csv-page-%d.html", i+1)
+		if !strings.Contains(string(index), name) || !strings.Contains(string(index), page.label) {
+			t.Fatalf("Index should link to %s holding rows %s", name, page.label)
+		}
+
+		b, err := os.ReadFile(utils.OutputDir() + "/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		count = strings.Count(string(b), "<tr>") - strings.Count(string(b), "No data was exported.")
+		if count != page.rows {
+			t.Fatalf("Expected %d rows on page %d, got %d", page.rows, i+1, count)
+		}
+	}
+
+	if _, err := os.Stat(utils.OutputDir() + "/100_sales_records.csv-page-5.html"); err == nil {
+		t.Fatal("No page should be written past the last row")
+	}
+}
+
+func TestInteractiveWriteOutputToFile(t *testing.T) {
+	reporter := report.NewMockReporter()
+	transformer := NewHTMLTransformer(reporter, Options{Interactive: true})
+
+	err := transformer.WriteOutputToFile(&Output{
+		FileName:     "interactive_output.csv",
+		TotalHeaders: len(utils.GetHeaders()),
+		Headers:      utils.GetHeaders(),
+		Data:         []utils.SalesRecord{{Region: "Europe", OrderID: 669165933}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(utils.OutputDir() + "/interactive_output.csv.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := string(b)
+	for _, external := range []string{"<link", "src="} {
+		if strings.Contains(doc, external) {
+			t.Fatalf("Interactive output should be self-contained, found '%s'", external)
+		}
+	}
+
+	for _, expected := range []string{"<style>", "<script>", `id="search"`, "<td>669165933</td>"} {
+		if !strings.Contains(doc, expected) {
+			t.Fatalf("Interactive output should contain '%s'", expected)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package transform
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/report"
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
+)
+
+// JSONTransformer handles the processing of customer data
+// with the aid of preprocessors and writing the output
+// either as a JSON array or as newline-delimited JSON.
+//
+// Every record is written as an object keyed by the csv tags
+// of the SalesRecord fields, in struct order.
+type JSONTransformer struct {
+	processor utils.PreProcessor
+	reporter  report.Reporter
+	options   Options
+	ndjson    bool
+}
+
+// NewJSONTransformer creates a new instance of a transformer
+// writing the records as a JSON array.
+//
+// Accepts a reporter for reporting purposes and the options
+// of the run.
+func NewJSONTransformer(reporter report.Reporter, opts Options) Transformer {
+	return &JSONTransformer{
+		processor: utils.NewProcessor(),
+		reporter:  reporter,
+		options:   opts,
+	}
+}
+
+// NewNDJSONTransformer creates a new instance of a transformer
+// writing the records as newline-delimited JSON, one object per line.
+//
+// Accepts a reporter for reporting purposes and the options
+// of the run.
+func NewNDJSONTransformer(reporter report.Reporter, opts Options) Transformer {
+	return &JSONTransformer{
+		processor: utils.NewProcessor(),
+		reporter:  reporter,
+		options:   opts,
+		ndjson:    true,
+	}
+}
+
+// ProcessRecord process records received via the chan
+func (tr *JSONTransformer) ProcessRecord(wg *sync.WaitGroup, record <-chan utils.Row, done <-chan bool) {
+	var (
+		now  = time.Now()
+		data []utils.SalesRecord
+	)
+
+	defer func() {
+		complete(tr.reporter, now)
+		wg.Done()
+	}()
+
+	// process pipeline
+	consume(tr.processor, tr.reporter, tr.options.Tolerances, record, done, nil, func(sr utils.SalesRecord) {
+		data = append(data, sr)
+	})
+
+	// send output to file
+	err := tr.WriteOutputToFile(&Output{
+		FileName:     filepath.Base(tr.reporter.GetFilename()),
+		TotalHeaders: len(tr.reporter.GetHeaders()),
+		Headers:      tr.reporter.GetHeaders(),
+		Data:         data,
+	})
+	if err != nil {
+		tr.reporter.AddError(err)
+	}
+}
+
+// WriteOutputToFile write output data to file.
+func (tr *JSONTransformer) WriteOutputToFile(output *Output) error {
+	ext := FormatJSON
+	if tr.ndjson {
+		ext = FormatNDJSON
+	}
+
+	// create output json file
+	f, err := createOutputFile(output.FileName, ext)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+
+	if !tr.ndjson {
+		if _, err = w.WriteString("["); err != nil {
+			return err
+		}
+	}
+
+	for i, sr := range output.Data {
+		b, err := marshalJSONRecord(sr)
+		if err != nil {
+			return err
+		}
+
+		if tr.ndjson {
+			// one object per line
+			b = append(b, '\n')
+		} else {
+			// separate array elements
+			sep := ",\n  "
+			if i == 0 {
+				sep = "\n  "
+			}
+
+			if _, err = w.WriteString(sep); err != nil {
+				return err
+			}
+		}
+
+		if _, err = w.Write(b); err != nil {
+			return err
+		}
+	}
+
+	if !tr.ndjson {
+		if _, err = w.WriteString("\n]\n"); err != nil {
+			return err
+		}
+	}
+
+	// flush buffer
+	return w.Flush()
+}
+
+// marshalJSONRecord encodes a record as a JSON object keyed by the
+// csv tags of its fields, keeping the order of the struct fields.
+func marshalJSONRecord(sr utils.SalesRecord) ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteByte('{')
+	for i, f := range fields(sr) {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		k, err := json.Marshal(f.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,94 @@
+package transform
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/parser"
+	"github.com/dele454/medium/csv-transform-to-html/internal/report"
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
+)
+
+func runJSONTransformer(t *testing.T, transformer Transformer, reporter report.Reporter) {
+	t.Helper()
+
+	path := utils.RootDir()
+	p := parser.NewCSVParser(path+"/internal/testdata/100_sales_records.csv", reporter, parser.Dialect{})
+
+	// create waitgroup
+	wg := new(sync.WaitGroup)
+	wg.Add(2)
+
+	// channels for pipeline
+	record := make(chan utils.Row)
+	done := make(chan bool)
+
+	go transformer.ProcessRecord(wg, record, done)
+	go p.Read(wg, record, done)
+
+	wg.Wait()
+
+	count := len(reporter.GetErrors())
+	expected := 0
+	if count != expected {
+		t.Fatalf("Expected %d, got %d", expected, count)
+	}
+}
+
+func TestJSONProcessRecord(t *testing.T) {
+	reporter := report.NewMockReporter()
+	runJSONTransformer(t, NewJSONTransformer(reporter, Options{}), reporter)
+
+	b, err := os.ReadFile(utils.OutputDir() + "/100_sales_records.csv.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var data []map[string]string
+	if err := json.Unmarshal(b, &data); err != nil {
+		t.Fatal(err)
+	}
+
+	count := len(data)
+	expected := 100
+	if count != expected {
+		t.Fatalf("Expected %d, got %d", expected, count)
+	}
+
+	if data[0]["Country"] != "Tuvalu" || data[0]["TotalProfit"] != "951410.50" {
+		t.Fatalf("Unexpected first record: %+v", data[0])
+	}
+}
+
+func TestNDJSONProcessRecord(t *testing.T) {
+	reporter := report.NewMockReporter()
+	runJSONTransformer(t, NewNDJSONTransformer(reporter, Options{}), reporter)
+
+	f, err := os.Open(utils.OutputDir() + "/100_sales_records.csv.ndjson")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var count int
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var row map[string]string
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			t.Fatalf("Line %d is not valid json: %v", count+1, err)
+		}
+
+		if len(row) != len(utils.GetHeaders()) {
+			t.Fatalf("Expected %d fields, got %d", len(utils.GetHeaders()), len(row))
+		}
+		count++
+	}
+
+	expected := 100
+	if count != expected {
+		t.Fatalf("Expected %d, got %d", expected, count)
+	}
+}
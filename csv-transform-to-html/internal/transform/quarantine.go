@@ -0,0 +1,163 @@
+package transform
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/errs"
+	"github.com/dele454/medium/csv-transform-to-html/internal/report"
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
+)
+
+// extra columns of the rejected file
+var quarantineHeaders = []string{"Line", "Error"}
+
+// quarantine writes the rows rejected during a transformation to
+// <name>.rejected.csv next to the output, e.g. sales.csv.rejected.csv,
+// so they can be fixed and resubmitted on their own.
+//
+// Rows are written as they were read from the source file, with
+// the line and reason they were rejected for as extra columns.
+// A row which cannot be split into values, e.g. for a stray
+// quote, is written whole into the first column. The file is
+// only created once a row is rejected.
+type quarantine struct {
+	reporter report.Reporter
+	file     *os.File
+	w        *csv.Writer
+	headers  int
+	failed   bool
+	// resumeAt size of the rejected file of an interrupted
+	// transformation to append to, if any
+	resumeAt int64
+}
+
+// newQuarantine creates a quarantine for the file being transformed
+func newQuarantine(reporter report.Reporter) *quarantine {
+	return &quarantine{reporter: reporter}
+}
+
+// quarantineName name of the rejected file of a source file
+//
+// e.g. sales.csv is quarantined to sales.csv.rejected, keeping the
+// extension as sales.tsv would otherwise share its rejected file.
+func quarantineName(name string) string {
+	return filepath.Base(name) + ".rejected"
+}
+
+// Write writes a rejected row along with the error it failed with
+//
+// Failing to write the file is reported once and further
+// rejected rows are dropped.
+func (q *quarantine) Write(row utils.Row, err error) {
+	if q.failed {
+		return
+	}
+
+	if q.w == nil {
+		if err := q.open(row); err != nil {
+			q.reporter.AddError(err)
+			q.failed = true
+			return
+		}
+	}
+
+	// rows which failed to be split into values are
+	// written whole into the first column
+	values := append([]string{}, row.Raw...)
+	if len(values) == 0 && row.Text != "" {
+		values = []string{row.Text}
+	}
+
+	// rows short of fields are padded to keep the extra
+	// columns aligned with the headers
+	for len(values) < q.headers {
+		values = append(values, "")
+	}
+
+	if err := q.w.Write(append(values, strconv.Itoa(row.Line), reason(err))); err != nil {
+		q.reporter.AddError(err)
+		q.failed = true
+	}
+}
+
+// Flush flushes the rejected file, returning its size
+func (q *quarantine) Flush() (int64, error) {
+	if q.w == nil {
+		return q.resumeAt, nil
+	}
+
+	q.w.Flush()
+	if err := q.w.Error(); err != nil {
+		return 0, err
+	}
+
+	return q.file.Seek(0, io.SeekCurrent)
+}
+
+// Close flushes and closes the rejected file, if any
+func (q *quarantine) Close() {
+	if q.w == nil {
+		return
+	}
+	defer q.file.Close()
+
+	q.w.Flush()
+	if err := q.w.Error(); err != nil {
+		q.reporter.AddError(err)
+	}
+}
+
+// open creates the rejected file and writes the headers of the
+// source file, in their original order, followed by the extra
+// columns.
+//
+// The rejected file of an interrupted transformation is appended
+// to instead.
+func (q *quarantine) open(row utils.Row) error {
+	var err error
+
+	name := quarantineName(q.reporter.GetFilename())
+	if q.resumeAt > 0 {
+		q.file, err = appendOutputFile(name, "csv", q.resumeAt)
+	} else {
+		q.file, err = createOutputFile(name, "csv")
+	}
+	if err != nil {
+		return err
+	}
+
+	// columns map the fields, in struct order, to the
+	// columns of the source file
+	expected := utils.GetHeaders()
+	headers := make([]string, len(row.Columns))
+	for i, col := range row.Columns {
+		if col < len(headers) && i < len(expected) {
+			headers[col] = expected[i]
+		}
+	}
+
+	q.headers = len(headers)
+	q.w = csv.NewWriter(q.file)
+
+	if q.resumeAt > 0 {
+		return nil
+	}
+
+	return q.w.Write(append(headers, quarantineHeaders...))
+}
+
+// reason message of the error a row was rejected for, without
+// its position which the extra columns already hold.
+func reason(err error) string {
+	var re *errs.RecordError
+	if errors.As(err, &re) {
+		return re.Err.Error()
+	}
+
+	return err.Error()
+}
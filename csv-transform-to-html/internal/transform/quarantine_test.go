@@ -0,0 +1,222 @@
+package transform
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/parser"
+	"github.com/dele454/medium/csv-transform-to-html/internal/report"
+	"github.com/dele454/medium/csv-transform-to-html/internal/rules"
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
+)
+
+func TestQuarantine(t *testing.T) {
+	// Country & Region are swapped, the 2nd row has an invalid
+	// OrderID and the 3rd row is short of a field
+	src := "Country,Region,ItemType,SalesChannel,OrderPriority,OrderDate,OrderID,ShipDate,UnitsSold,UnitPrice,UnitCost,TotalRevenue,TotalCost,TotalProfit\n" +
+		"Tuvalu,Australia and Oceania,Baby Food,Offline,H,5/28/2010,669165933,6/27/2010,9925,255.28,159.42,2533654.00,1582243.50,951410.50\n" +
+		"Grenada,Central America and the Caribbean,Cereal,Online,C,8/22/2012,96388x480,9/15/2012,2804,205.70,117.11,576782.80,328376.44,248406.36\n" +
+		"Russia,Europe,Office Supplies,Offline,L,5/2/2014,341417157,5/8/2014,1779,651.21,524.96,1158502.59,933903.84\n"
+
+	path := filepath.Join(t.TempDir(), "quarantined_sales_records.csv")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reporter := report.NewMockReporter()
+	transformer := NewJSONTransformer(reporter, Options{})
+	p := parser.NewCSVParser(path, reporter, parser.Dialect{})
+
+	// create waitgroup
+	wg := new(sync.WaitGroup)
+	wg.Add(2)
+
+	// channels for pipeline
+	record := make(chan utils.Row)
+	done := make(chan bool)
+
+	go transformer.ProcessRecord(wg, record, done)
+	go p.Read(wg, record, done)
+
+	wg.Wait()
+
+	f, err := os.Open(utils.OutputDir() + "/quarantined_sales_records.csv.rejected.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) != 3 {
+		t.Fatalf("Expected headers & 2 rejected rows, got %d rows", len(rows))
+	}
+
+	// headers in the order of the source file
+	if rows[0][0] != "Country" || rows[0][14] != "Line" || rows[0][15] != "Error" {
+		t.Fatalf("Unexpected headers %v", rows[0])
+	}
+
+	// raw rows with their line & reason
+	expected := []struct {
+		country string
+		column  int
+		value   string
+		line    string
+		reason  string
+	}{
+		{"Grenada", 6, "96388x480", "3", "'OrderID' Field is not valid."},
+		{"Russia", 13, "", "4", "wrong number of fields"},
+	}
+
+	for i, e := range expected {
+		row := rows[i+1]
+		if len(row) != 16 || row[0] != e.country || row[e.column] != e.value || row[14] != e.line || row[15] != e.reason {
+			t.Fatalf("\nRow Mismatch:\nExpected: %+v\nGot: %v", e, row)
+		}
+	}
+}
+
+func TestQuarantineRuleViolations(t *testing.T) {
+	// the 1st row is shipped before being ordered, the 2nd row has
+	// both its revenue and profit off and the 3rd row is consistent
+	src := "Region,Country,ItemType,SalesChannel,OrderPriority,OrderDate,OrderID,ShipDate,UnitsSold,UnitPrice,UnitCost,TotalRevenue,TotalCost,TotalProfit\n" +
+		"Australia and Oceania,Tuvalu,Baby Food,Offline,H,5/28/2010,669165933,5/27/2010,9925,255.28,159.42,2533654.00,1582243.50,951410.50\n" +
+		"Central America and the Caribbean,Grenada,Cereal,Online,C,8/22/2012,963881480,9/15/2012,2804,205.70,117.11,576783.80,328376.44,248406.36\n" +
+		"Europe,Russia,Office Supplies,Offline,L,5/2/2014,341417157,5/8/2014,1779,651.21,524.96,1158502.59,933903.84,224598.75\n"
+
+	path := filepath.Join(t.TempDir(), "inconsistent_sales_records.csv")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reporter := report.NewMockReporter()
+	transformer := NewJSONTransformer(reporter, Options{Tolerances: rules.DefaultTolerances})
+	p := parser.NewCSVParser(path, reporter, parser.Dialect{})
+
+	wg := new(sync.WaitGroup)
+	wg.Add(2)
+
+	record := make(chan utils.Row)
+	done := make(chan bool)
+
+	go transformer.ProcessRecord(wg, record, done)
+	go p.Read(wg, record, done)
+
+	wg.Wait()
+
+	if reporter.GetTotalFailedRecords() != 2 || reporter.GetTotalTransformedRecords() != 1 {
+		t.Fatalf("Expected 2 failed & 1 transformed, got %d & %d",
+			reporter.GetTotalFailedRecords(), reporter.GetTotalTransformedRecords())
+	}
+
+	// every violation is reported against its cell
+	expectedErrors := []string{
+		"Line 2, Column 8, Field 'ShipDate', Value '5/27/2010': 'ShipDate' Field must not be before the OrderDate 5/28/2010.",
+		"Line 3, Column 12, Field 'TotalRevenue', Value '576783.80': 'TotalRevenue' Field must equal UnitsSold × UnitPrice, 576782.80.",
+		"Line 3, Column 14, Field 'TotalProfit', Value '248406.36': 'TotalProfit' Field must equal TotalRevenue − TotalCost, 248407.36.",
+	}
+
+	errors := reporter.GetErrors()
+	if len(errors) != len(expectedErrors) {
+		t.Fatalf("Expected %d errors, got %v", len(expectedErrors), errors)
+	}
+
+	for i, e := range expectedErrors {
+		if errors[i].Error() != e {
+			t.Fatalf("\nError Mismatch:\nExpected: %v\nGot: %v", e, errors[i])
+		}
+	}
+
+	f, err := os.Open(utils.OutputDir() + "/inconsistent_sales_records.csv.rejected.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a row is rejected once with all of its violations
+	if len(rows) != 3 || rows[2][14] != "3" ||
+		rows[2][15] != "'TotalRevenue' Field must equal UnitsSold × UnitPrice, 576782.80. 'TotalProfit' Field must equal TotalRevenue − TotalCost, 248407.36." {
+		t.Fatalf("Unexpected rejected rows %v", rows)
+	}
+}
+
+func TestQuarantineMalformedRow(t *testing.T) {
+	// the 2nd row holds a stray quote and cannot be split into values
+	malformed := `Grenada,Central America and the Caribbean,Cereal,Online,C,8/22/2012,963881480,9/15/2012,28"04,205.70,117.11,576782.80,328376.44,248406.36`
+	src := "Country,Region,ItemType,SalesChannel,OrderPriority,OrderDate,OrderID,ShipDate,UnitsSold,UnitPrice,UnitCost,TotalRevenue,TotalCost,TotalProfit\n" +
+		"Tuvalu,Australia and Oceania,Baby Food,Offline,H,5/28/2010,669165933,6/27/2010,9925,255.28,159.42,2533654.00,1582243.50,951410.50\n" +
+		malformed + "\r\n" +
+		"Russia,Europe,Office Supplies,Offline,L,5/2/2014,341417157,5/8/2014,1779,651.21,524.96,1158502.59,933903.84,224598.75\n"
+
+	path := filepath.Join(t.TempDir(), "malformed_sales_records.csv")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reporter := report.NewMockReporter()
+	transformer := NewJSONTransformer(reporter, Options{})
+	p := parser.NewCSVParser(path, reporter, parser.Dialect{})
+
+	wg := new(sync.WaitGroup)
+	wg.Add(2)
+
+	record := make(chan utils.Row)
+	done := make(chan bool)
+
+	go transformer.ProcessRecord(wg, record, done)
+	go p.Read(wg, record, done)
+
+	wg.Wait()
+
+	if reporter.GetTotalFailedRecords() != 1 || reporter.GetTotalTransformedRecords() != 2 {
+		t.Fatalf("Expected 1 failed & 2 transformed, got %d & %d",
+			reporter.GetTotalFailedRecords(), reporter.GetTotalTransformedRecords())
+	}
+
+	f, err := os.Open(utils.OutputDir() + "/malformed_sales_records.csv.rejected.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the row is written as read, line break aside
+	if len(rows) != 2 || rows[1][0] != malformed || rows[1][14] != "3" || rows[1][15] != `bare " in non-quoted-field` {
+		t.Fatalf("Unexpected rejected rows %q", rows)
+	}
+}
+
+func TestQuarantineName(t *testing.T) {
+	// files differing by extension only are quarantined apart
+	names := map[string]string{
+		"sales.csv":         "sales.csv.rejected",
+		"/data/sales.tsv":   "sales.tsv.rejected",
+		"sales.xlsx":        "sales.xlsx.rejected",
+		"exports/sales.csv": "sales.csv.rejected",
+	}
+
+	for name, expected := range names {
+		if got := quarantineName(name); got != expected {
+			t.Fatalf("\nName Mismatch:\nExpected: %v\nGot: %v", expected, got)
+		}
+	}
+}
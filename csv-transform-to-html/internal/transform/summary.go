@@ -0,0 +1,120 @@
+package transform
+
+import (
+	"sort"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
+	"github.com/shopspring/decimal"
+)
+
+// summaryFields fields the transformed records are grouped by
+var summaryFields = []string{"Region", "ItemType", "SalesChannel", "OrderPriority"}
+
+// Summary aggregated totals & averages of the transformed records
+// grouped by the categorical fields of a SalesRecord.
+type Summary struct {
+	Groups []*SummaryGroup
+	Total  *Totals
+}
+
+// SummaryGroup totals for every distinct value of a field
+type SummaryGroup struct {
+	Field  string
+	Totals []*Totals
+	index  map[string]*Totals
+}
+
+// Totals sums of the numeric fields over a set of records
+//
+// Amounts are summed exactly rather than as floats.
+type Totals struct {
+	Key          string
+	Records      int
+	UnitsSold    int64
+	TotalRevenue decimal.Decimal
+	TotalCost    decimal.Decimal
+	TotalProfit  decimal.Decimal
+}
+
+// NewSummary creates an empty summary
+func NewSummary() *Summary {
+	s := &Summary{Total: &Totals{Key: "Total"}}
+
+	for _, f := range summaryFields {
+		s.Groups = append(s.Groups, &SummaryGroup{
+			Field: f,
+			index: make(map[string]*Totals),
+		})
+	}
+
+	return s
+}
+
+// Add adds a record to the summary
+func (s *Summary) Add(sr utils.SalesRecord) {
+	values := map[string]string{
+		"Region":        sr.Region,
+		"ItemType":      sr.ItemType,
+		"SalesChannel":  sr.SalesChannel,
+		"OrderPriority": sr.OrderPriority,
+	}
+
+	s.Total.add(sr)
+	for _, g := range s.Groups {
+		g.add(values[g.Field], sr)
+	}
+}
+
+// add adds a record to the totals of the given value
+func (g *SummaryGroup) add(key string, sr utils.SalesRecord) {
+	t, ok := g.index[key]
+	if !ok {
+		t = &Totals{Key: key}
+		g.index[key] = t
+
+		// keep totals sorted by key
+		i := sort.Search(len(g.Totals), func(i int) bool { return g.Totals[i].Key >= key })
+		g.Totals = append(g.Totals, nil)
+		copy(g.Totals[i+1:], g.Totals[i:])
+		g.Totals[i] = t
+	}
+
+	t.add(sr)
+}
+
+// add adds the numeric fields of a record to the totals
+func (t *Totals) add(sr utils.SalesRecord) {
+	t.Records++
+	t.UnitsSold += sr.UnitsSold
+	t.TotalRevenue = t.TotalRevenue.Add(sr.TotalRevenue)
+	t.TotalCost = t.TotalCost.Add(sr.TotalCost)
+	t.TotalProfit = t.TotalProfit.Add(sr.TotalProfit)
+}
+
+// AvgUnitsSold average units sold per record
+func (t *Totals) AvgUnitsSold() decimal.Decimal {
+	return t.avg(decimal.NewFromInt(t.UnitsSold))
+}
+
+// AvgTotalRevenue average revenue per record
+func (t *Totals) AvgTotalRevenue() decimal.Decimal {
+	return t.avg(t.TotalRevenue)
+}
+
+// AvgTotalCost average cost per record
+func (t *Totals) AvgTotalCost() decimal.Decimal {
+	return t.avg(t.TotalCost)
+}
+
+// AvgTotalProfit average profit per record
+func (t *Totals) AvgTotalProfit() decimal.Decimal {
+	return t.avg(t.TotalProfit)
+}
+
+func (t *Totals) avg(sum decimal.Decimal) decimal.Decimal {
+	if t.Records == 0 {
+		return decimal.Zero
+	}
+
+	return sum.Div(decimal.NewFromInt(int64(t.Records)))
+}
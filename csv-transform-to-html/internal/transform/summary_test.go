@@ -0,0 +1,56 @@
+package transform
+
+import (
+	"testing"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
+)
+
+func TestSummary(t *testing.T) {
+	s := NewSummary()
+
+	records := []utils.SalesRecord{
+		{Region: "Europe", ItemType: "Cereal", SalesChannel: "Online", OrderPriority: "H", UnitsSold: 10, TotalRevenue: amount("100.50"), TotalCost: amount("50.25"), TotalProfit: amount("50.25")},
+		{Region: "Asia", ItemType: "Cereal", SalesChannel: "Offline", OrderPriority: "L", UnitsSold: 30, TotalRevenue: amount("300.00"), TotalCost: amount("100.00"), TotalProfit: amount("200.00")},
+		{Region: "Europe", ItemType: "Fruits", SalesChannel: "Online", OrderPriority: "H", UnitsSold: 20, TotalRevenue: amount("200.00"), TotalCost: amount("150.00"), TotalProfit: amount("50.00")},
+	}
+
+	for _, sr := range records {
+		s.Add(sr)
+	}
+
+	if s.Total.Records != 3 || s.Total.UnitsSold != 60 || !s.Total.TotalRevenue.Equal(amount("600.50")) {
+		t.Fatalf("Unexpected grand total: %+v", s.Total)
+	}
+
+	if !s.Total.AvgUnitsSold().Equal(amount("20")) {
+		t.Fatalf("Expected %v, got %v", 20, s.Total.AvgUnitsSold())
+	}
+
+	if len(s.Groups) != len(summaryFields) {
+		t.Fatalf("Expected %d groups, got %d", len(summaryFields), len(s.Groups))
+	}
+
+	region := s.Groups[0]
+	if region.Field != "Region" || len(region.Totals) != 2 {
+		t.Fatalf("Unexpected region group: %+v", region)
+	}
+
+	// totals are sorted by key
+	asia, europe := region.Totals[0], region.Totals[1]
+	if asia.Key != "Asia" || europe.Key != "Europe" {
+		t.Fatalf("Expected Asia before Europe, got %s before %s", asia.Key, europe.Key)
+	}
+
+	if europe.Records != 2 || europe.UnitsSold != 30 || !europe.TotalProfit.Equal(amount("100.25")) {
+		t.Fatalf("Unexpected europe totals: %+v", europe)
+	}
+
+	if !europe.AvgTotalCost().Equal(amount("100.125")) {
+		t.Fatalf("Expected %v, got %v", 100.125, europe.AvgTotalCost())
+	}
+
+	if !(&Totals{}).AvgTotalRevenue().IsZero() {
+		t.Fatal("Average of no records should be zero")
+	}
+}
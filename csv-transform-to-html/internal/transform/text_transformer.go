@@ -0,0 +1,277 @@
+package transform
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/report"
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
+)
+
+// TextTransformer handles the processing of customer data
+// with the aid of preprocessors and writing the output
+// either as a GitHub-flavoured Markdown table or as an aligned
+// plain-text table, followed by the summary of the report.
+//
+// Columns are aligned to their widest value so all records are
+// kept in memory until the output is written.
+type TextTransformer struct {
+	processor utils.PreProcessor
+	reporter  report.Reporter
+	options   Options
+	markdown  bool
+}
+
+// NewMarkdownTransformer creates a new instance of a transformer
+// writing the records as a Markdown table.
+//
+// Accepts a reporter for reporting purposes and the options
+// of the run.
+func NewMarkdownTransformer(reporter report.Reporter, opts Options) Transformer {
+	return &TextTransformer{
+		processor: utils.NewProcessor(),
+		reporter:  reporter,
+		options:   opts,
+		markdown:  true,
+	}
+}
+
+// NewTextTransformer creates a new instance of a transformer
+// writing the records as an aligned plain-text table.
+//
+// Accepts a reporter for reporting purposes and the options
+// of the run.
+func NewTextTransformer(reporter report.Reporter, opts Options) Transformer {
+	return &TextTransformer{
+		processor: utils.NewProcessor(),
+		reporter:  reporter,
+		options:   opts,
+	}
+}
+
+// ProcessRecord process records received via the chan
+func (tr *TextTransformer) ProcessRecord(wg *sync.WaitGroup, record <-chan utils.Row, done <-chan bool) {
+	var (
+		now  = time.Now()
+		data []utils.SalesRecord
+	)
+
+	defer wg.Done()
+
+	// process pipeline
+	consume(tr.processor, tr.reporter, tr.options.Tolerances, record, done, nil, func(sr utils.SalesRecord) {
+		data = append(data, sr)
+	})
+
+	// the output holds the summary of the report
+	// so is written once the transformation completes
+	finish(tr.reporter, now)
+
+	// send output to file
+	err := tr.WriteOutputToFile(&Output{
+		FileName:     filepath.Base(tr.reporter.GetFilename()),
+		TotalHeaders: len(tr.reporter.GetHeaders()),
+		Headers:      tr.reporter.GetHeaders(),
+		Data:         data,
+	})
+	if err != nil {
+		tr.reporter.AddError(err)
+	}
+
+	writeReport(tr.reporter)
+}
+
+// WriteOutputToFile write output data to file.
+func (tr *TextTransformer) WriteOutputToFile(output *Output) error {
+	var (
+		rows  = make([][]string, 0, len(output.Data))
+		right = make([]bool, len(output.Headers))
+	)
+
+	// numeric columns are right aligned
+	for i, f := range fields(utils.SalesRecord{}) {
+		if i < len(right) {
+			right[i] = f.has("amount") || f.has("numeric")
+		}
+	}
+
+	for _, sr := range output.Data {
+		list := fields(sr)
+		row := make([]string, len(list))
+		for i, f := range list {
+			row[i] = f.Value
+		}
+		rows = append(rows, row)
+	}
+
+	ext := FormatTXT
+	if tr.markdown {
+		ext = FormatMarkdown
+	}
+
+	// create output file
+	f, err := createOutputFile(output.FileName, ext)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+
+	t := &textTable{headers: output.Headers, rows: rows, right: right, markdown: tr.markdown}
+	if tr.markdown {
+		fmt.Fprintf(w, "# %s\n\n", escapeMarkdown(output.FileName))
+	} else {
+		fmt.Fprintf(w, "%s\n\n", output.FileName)
+	}
+
+	t.write(w)
+	tr.writeSummary(w)
+
+	// flush buffer
+	return w.Flush()
+}
+
+// writeSummary writes the summary of the report below the table
+func (tr *TextTransformer) writeSummary(w *bufio.Writer) {
+	summary := &textTable{
+		headers:  []string{"Report", "Value"},
+		markdown: tr.markdown,
+		rows: [][]string{
+			{"Total Processed Records", fmt.Sprint(tr.reporter.GetTotalProcessedRecords())},
+			{"Total Failed Records", fmt.Sprint(tr.reporter.GetTotalFailedRecords())},
+			{"Total Transformed Records", fmt.Sprint(tr.reporter.GetTotalTransformedRecords())},
+			{"Duration", tr.reporter.GetDuration()},
+			{"Completed At", tr.reporter.GetCompletedAt()},
+		},
+	}
+
+	if tr.markdown {
+		w.WriteString("\n## Report\n\n")
+	} else {
+		w.WriteString("\n")
+	}
+	summary.write(w)
+
+	if tr.markdown {
+		w.WriteString("\n### Errors\n\n")
+	} else {
+		w.WriteString("\nErrors:\n")
+	}
+
+	for _, err := range tr.reporter.GetErrors() {
+		msg := err.Error()
+		if tr.markdown {
+			msg = escapeMarkdown(msg)
+		}
+		fmt.Fprintf(w, "- %s\n", msg)
+	}
+
+	if len(tr.reporter.GetErrors()) == 0 {
+		w.WriteString("No Errors\n")
+	}
+}
+
+// textTable renders rows as a table aligned to the widest
+// value of each column.
+type textTable struct {
+	headers  []string
+	rows     [][]string
+	right    []bool
+	markdown bool
+}
+
+// write writes the table, framed by borders when plain-text
+func (t *textTable) write(w *bufio.Writer) {
+	widths := make([]int, len(t.headers))
+	cell := func(row []string, i int) string {
+		if i >= len(row) {
+			return ""
+		}
+		if t.markdown {
+			return escapeMarkdown(row[i])
+		}
+		return row[i]
+	}
+
+	for i := range t.headers {
+		widths[i] = utf8.RuneCountInString(cell(t.headers, i))
+		if t.markdown && widths[i] < 3 {
+			widths[i] = 3
+		}
+
+		for _, row := range t.rows {
+			if n := utf8.RuneCountInString(cell(row, i)); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	line := func(row []string) {
+		cells := make([]string, len(widths))
+		for i, width := range widths {
+			cells[i] = pad(cell(row, i), width, i < len(t.right) && t.right[i])
+		}
+		fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+	}
+
+	border := func() {
+		parts := make([]string, len(widths))
+		for i, width := range widths {
+			parts[i] = strings.Repeat("-", width+2)
+		}
+		fmt.Fprintf(w, "+%s+\n", strings.Join(parts, "+"))
+	}
+
+	if t.markdown {
+		line(t.headers)
+
+		// alignment row
+		parts := make([]string, len(widths))
+		for i, width := range widths {
+			parts[i] = strings.Repeat("-", width)
+			if i < len(t.right) && t.right[i] {
+				parts[i] = strings.Repeat("-", width-1) + ":"
+			}
+		}
+		fmt.Fprintf(w, "| %s |\n", strings.Join(parts, " | "))
+
+		for _, row := range t.rows {
+			line(row)
+		}
+
+		return
+	}
+
+	border()
+	line(t.headers)
+	border()
+	for _, row := range t.rows {
+		line(row)
+	}
+	border()
+}
+
+// pad pads a value with spaces to the given width
+func pad(s string, width int, right bool) string {
+	n := width - utf8.RuneCountInString(s)
+	if n <= 0 {
+		return s
+	}
+
+	if right {
+		return strings.Repeat(" ", n) + s
+	}
+
+	return s + strings.Repeat(" ", n)
+}
+
+// escapeMarkdown escapes characters which would break a Markdown table
+func escapeMarkdown(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\n", " ").Replace(s)
+}
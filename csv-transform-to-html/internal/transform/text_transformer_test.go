@@ -0,0 +1,83 @@
+package transform
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/report"
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
+)
+
+func textOutput() *Output {
+	return &Output{
+		FileName:     "text_output.csv",
+		TotalHeaders: len(utils.GetHeaders()),
+		Headers:      utils.GetHeaders(),
+		Data: []utils.SalesRecord{
+			{Region: "Europe", Country: "Bosnia | Herzegovina", UnitsSold: 9925},
+			{Region: "Asia", Country: "Japan", UnitsSold: 12},
+		},
+	}
+}
+
+func TestMarkdownWriteOutputToFile(t *testing.T) {
+	reporter := report.NewMockReporter()
+	reporter.AddError(os.ErrNotExist)
+
+	err := NewMarkdownTransformer(reporter, Options{}).WriteOutputToFile(textOutput())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(utils.OutputDir() + "/text_output.csv.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := string(b)
+	for _, expected := range []string{
+		"| Region | Country ",
+		`Bosnia \| Herzegovina`,
+		"## Report",
+		"- " + os.ErrNotExist.Error(),
+	} {
+		if !strings.Contains(doc, expected) {
+			t.Fatalf("Markdown output should contain '%s'", expected)
+		}
+	}
+
+	// header, alignment row & one row per record, all of the same width
+	lines := strings.Split(doc, "\n")[2:6]
+	for _, l := range lines[1:] {
+		if len(l) != len(lines[0]) {
+			t.Fatalf("Table rows should be aligned:\n%s", strings.Join(lines, "\n"))
+		}
+	}
+
+	// numeric columns are right aligned
+	if !strings.Contains(lines[1], "--------: |") {
+		t.Fatalf("UnitsSold should be right aligned: %s", lines[1])
+	}
+}
+
+func TestTextWriteOutputToFile(t *testing.T) {
+	reporter := report.NewMockReporter()
+
+	err := NewTextTransformer(reporter, Options{}).WriteOutputToFile(textOutput())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(utils.OutputDir() + "/text_output.csv.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := string(b)
+	for _, expected := range []string{"+--------+", "| Europe | Bosnia | Herzegovina |", "|        12 |", "No Errors"} {
+		if !strings.Contains(doc, expected) {
+			t.Fatalf("Text output should contain '%s'\n%s", expected, doc)
+		}
+	}
+}
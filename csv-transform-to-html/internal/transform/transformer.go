@@ -0,0 +1,351 @@
+package transform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/checkpoint"
+	"github.com/dele454/medium/csv-transform-to-html/internal/errs"
+	"github.com/dele454/medium/csv-transform-to-html/internal/report"
+	"github.com/dele454/medium/csv-transform-to-html/internal/rules"
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
+	"github.com/shopspring/decimal"
+)
+
+// Output details of the transformation sent to an io.Writer
+type Output struct {
+	FileName     string
+	FileLocation string
+	TotalHeaders int
+	Headers      []string
+	TotalRecords int
+	Data         []utils.SalesRecord
+	Summary      *Summary
+	Charts       *Charts
+
+	// links to the other documents of a paginated output
+	Page  int
+	Index string
+	Prev  string
+	Next  string
+}
+
+// Transformer ops every transformer should conform to
+type Transformer interface {
+	WriteOutputToFile(output *Output) error
+	ProcessRecord(wg *sync.WaitGroup, record <-chan utils.Row, done <-chan bool)
+}
+
+// Supported output formats
+const (
+	FormatHTML     = "html"
+	FormatXML      = "xml"
+	FormatJSON     = "json"
+	FormatNDJSON   = "ndjson"
+	FormatXLSX     = "xlsx"
+	FormatMarkdown = "md"
+	FormatTXT      = "txt"
+)
+
+// Options tune how a transformer writes its output
+type Options struct {
+	// PageSize splits the html output into pages holding the given
+	// nos of rows plus an index page. Zero writes a single document.
+	PageSize int
+	// Interactive writes a self-contained html output with inlined
+	// styles & scripts to sort, filter and search the table.
+	Interactive bool
+	// Summary adds totals & averages of the numeric fields grouped
+	// by the categorical fields to the html output.
+	Summary bool
+	// Charts adds inline svg charts of revenue by region, profit by
+	// month and revenue share by sales channel to the html output.
+	Charts bool
+	// Checkpoint progress of the transformation, persisted every
+	// CheckpointEvery rows so it can be resumed if interrupted.
+	// The transformation resumes from it if rows were handled.
+	Checkpoint      *checkpoint.Checkpoint
+	CheckpointEvery int
+	// Tolerances largest differences allowed between the totals
+	// of a record and the totals worked out from its other fields.
+	// Zero tolerances require the totals to match exactly.
+	Tolerances rules.Tolerances
+}
+
+// NewTransformer creates a transformer for the requested output format
+func NewTransformer(format string, reporter report.Reporter, opts Options) (Transformer, error) {
+	switch strings.ToLower(format) {
+	case FormatHTML, "":
+		return NewHTMLTransformer(reporter, opts), nil
+	case FormatXML:
+		return NewXMLTransformer(reporter, opts), nil
+	case FormatJSON:
+		return NewJSONTransformer(reporter, opts), nil
+	case FormatNDJSON:
+		return NewNDJSONTransformer(reporter, opts), nil
+	case FormatXLSX:
+		return NewXLSXTransformer(reporter, opts), nil
+	case FormatMarkdown:
+		return NewMarkdownTransformer(reporter, opts), nil
+	case FormatTXT:
+		return NewTextTransformer(reporter, opts), nil
+	default:
+		return nil, fmt.Errorf(errs.ErrorUnsupportedFormat.Error(), format)
+	}
+}
+
+// consume reads rows off the pipeline until the parser signals the
+// end of the file, unmarshalling each row into a SalesRecord and
+// handing it over to fn.
+//
+// Rows failing to be read or unmarshalled, or breaking a rule of
+// the rules package with the given tolerances, are quarantined.
+// The progress is checkpointed along the way if a checkpointer
+// is given.
+func consume(processor utils.PreProcessor, reporter report.Reporter, tolerances rules.Tolerances,
+	record <-chan utils.Row, done <-chan bool, cp *checkpointer, fn func(sr utils.SalesRecord)) {
+	var (
+		end      bool
+		rejected = newQuarantine(reporter)
+	)
+
+	defer rejected.Close()
+
+	// rejected rows are appended to those
+	// of the interrupted transformation
+	if cp.resuming() {
+		rejected.resumeAt = cp.state.Rejected
+	}
+
+	advance := func(row utils.Row, transformed bool) {
+		if err := cp.advance(row, transformed, rejected); err != nil {
+			reporter.AddError(err)
+			cp = nil
+		}
+	}
+
+	for {
+		select {
+		case <-done:
+			// reading has completed.
+			end = true
+		case row := <-record:
+			// the parser failed to read the row
+			// and has reported it already
+			if row.Err != nil {
+				rejected.Write(row, row.Err)
+				advance(row, false)
+				continue
+			}
+
+			// read from pipeline
+			if len(row.Values) == 0 {
+				err := &errs.RecordError{Line: row.Line, Err: errs.ErrorEmptyRowFound}
+
+				reporter.RecordFailed()
+				reporter.AddError(err)
+				rejected.Write(row, err)
+
+				utils.Log(utils.ColorError, errs.ErrorEmptyRowFound)
+				advance(row, false)
+				continue
+			}
+
+			// unmarshal records
+			var sr utils.SalesRecord
+			sr, err := processor.Unmarshal(row.Values, sr)
+			if err != nil {
+				err = recordError(row, err)
+
+				reporter.RecordFailed()
+				reporter.AddError(err)
+				rejected.Write(row, err)
+				advance(row, false)
+
+				continue
+			}
+
+			// check the relationships between the fields,
+			// reporting every rule the row breaks
+			if violations := rules.Check(sr, tolerances); len(violations) > 0 {
+				reasons := make([]string, len(violations))
+				for i, v := range violations {
+					err := recordError(row, v)
+					reporter.AddError(err)
+					reasons[i] = reason(err)
+				}
+
+				reporter.RecordFailed()
+				rejected.Write(row, &errs.RecordError{Line: row.Line, Err: errors.New(strings.Join(reasons, " "))})
+				advance(row, false)
+
+				continue
+			}
+
+			reporter.RecordTransformed()
+			fn(sr)
+			advance(row, true)
+		}
+
+		// means parser has signaled end of file
+		// exit loop
+		if end {
+			break
+		}
+	}
+}
+
+// recordError adds the position of the row, and of the offending
+// cell if known, to an error found unmarshalling the row.
+func recordError(row utils.Row, err error) error {
+	re := &errs.RecordError{Line: row.Line, Err: err}
+
+	var fe *errs.FieldError
+	if errors.As(err, &fe) {
+		re.Field = fe.Field
+		re.Value = fe.Value
+		re.Err = fe.Err
+		if fe.Index < len(row.Columns) {
+			re.Column = row.Columns[fe.Index] + 1
+		}
+	}
+
+	return re
+}
+
+// complete wraps up the transformation and writes the report to stdout
+func complete(reporter report.Reporter, start time.Time) {
+	finish(reporter, start)
+	writeReport(reporter)
+}
+
+// finish records the duration & completion of the transformation
+func finish(reporter report.Reporter, start time.Time) {
+	reporter.SetFilename(filepath.Base(reporter.GetFilename()))
+	reporter.AddDuration(time.Since(start).Seconds())
+	reporter.Completed()
+}
+
+// writeReport writes the report to stdout
+func writeReport(reporter report.Reporter) {
+	err := reporter.WriteReportToStdOut(context.Background())
+	if err != nil {
+		utils.Log(utils.ColorError, err)
+	}
+}
+
+// createOutputFile creates the output folder if not exists and
+// a file within it for the given name & extension.
+func createOutputFile(name, ext string) (*os.File, error) {
+	folder := utils.OutputDir()
+
+	if _, err := os.Stat(folder); os.IsNotExist(err) {
+		err := os.MkdirAll(folder, os.ModePerm)
+		if err != nil {
+			return nil, fmt.Errorf(errs.ErrorFailedToCreateDirectory.Error(), err)
+		}
+	}
+
+	return os.Create(outputPath(name, ext))
+}
+
+// appendOutputFile reopens a file of the output folder for the given
+// name & extension, truncated to the given size and positioned at its
+// end to be appended to.
+//
+// Anything written past the size, after the last checkpoint of an
+// interrupted transformation, is discarded.
+func appendOutputFile(name, ext string, size int64) (*os.File, error) {
+	f, err := os.OpenFile(outputPath(name, ext), os.O_WRONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = f.Truncate(size); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if _, err = f.Seek(size, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// outputPath path of the file of the output folder for the
+// given name & extension
+func outputPath(name, ext string) string {
+	return filepath.Join(utils.OutputDir(), name+"."+ext)
+}
+
+// field a single value of a sales record keyed by its csv tag
+type field struct {
+	Name string
+	// Value value formatted as text
+	Value string
+	// Typed value as unmarshalled, i.e a string, int64,
+	// time.Time or decimal.Decimal
+	Typed interface{}
+	Tags  []string
+}
+
+// has reports if the field carries the given processor tag
+func (f field) has(tag string) bool {
+	for _, t := range f.Tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fields lists the values of a sales record in struct order keyed by
+// their csv tags.
+//
+// Values are unescaped since the processor escapes them for HTML and
+// every other format applies its own escaping.
+func fields(sr utils.SalesRecord) []field {
+	v := reflect.ValueOf(sr)
+	s := v.Type()
+
+	list := make([]field, 0, s.NumField())
+	for i := 0; i < s.NumField(); i++ {
+		typed := v.Field(i).Interface()
+
+		list = append(list, field{
+			Name:  s.Field(i).Tag.Get("csv"),
+			Value: format(typed),
+			Typed: typed,
+			Tags:  utils.SplitTags(s.Field(i).Tag.Get("processor")),
+		})
+	}
+
+	return list
+}
+
+// format formats the value of a field as text, the way it
+// was laid out in the source file
+func format(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return html.UnescapeString(v)
+	case time.Time:
+		return utils.FormatDate(v)
+	case decimal.Decimal:
+		return utils.FormatAmount(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,67 @@
+package transform
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/errs"
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
+	"github.com/shopspring/decimal"
+)
+
+// TestMain writes the outputs of the tests to a temporary folder
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "output")
+	if err != nil {
+		panic(err)
+	}
+
+	utils.SetOutputDir(dir)
+	code := m.Run()
+
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// date parses the date of a test record
+func date(s string) time.Time {
+	d, _ := time.Parse(utils.DateLayout, s)
+	return d
+}
+
+// amount parses an amount of a test record
+func amount(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func TestRecordError(t *testing.T) {
+	var p utils.Processor
+
+	// Country is read from the 5th column of the file
+	row := utils.Row{
+		Line:    12,
+		Values:  []string{"Australia and Oceania", "", "Baby Food", "Offline", "H", "5/28/2010", "669165933", "6/27/2010", "9925", "255.28", "159.42", "2533654.00", "1582243.50", "951410.50"},
+		Columns: []int{0, 4, 1, 2, 3, 5, 6, 7, 8, 9, 10, 11, 12, 13},
+	}
+
+	_, err := p.Unmarshal(row.Values, utils.SalesRecord{})
+	if err == nil {
+		t.Fatal("Expected Country to be required")
+	}
+
+	var re *errs.RecordError
+	if !errors.As(recordError(row, err), &re) {
+		t.Fatalf("Expected a record error, got %T", err)
+	}
+
+	if re.Line != 12 || re.Column != 5 || re.Field != "Country" || re.Value != "" {
+		t.Fatalf("\nPosition Mismatch:\nExpected: line 12, column 5, Country\nGot: %+v", re)
+	}
+
+	expected := "Line 12, Column 5, Field 'Country', Value '': 'Country' Field cannot be empty."
+	if re.Error() != expected {
+		t.Fatalf("\nMessage Mismatch:\nExpected: %v\nGot: %v", expected, re.Error())
+	}
+}
@@ -0,0 +1,295 @@
+package transform
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/report"
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
+	"github.com/shopspring/decimal"
+	"github.com/xuri/excelize/v2"
+)
+
+// names of the sheets of the workbook
+const (
+	xlsxDataSheet   = "Data"
+	xlsxReportSheet = "Report"
+)
+
+// XLSXTransformer handles the processing of customer data
+// with the aid of preprocessors and writing the output
+// as an Excel workbook.
+//
+// The Data sheet holds a row per record with typed cells, going by
+// the processor tags of each field: amounts and numerics are written
+// as numbers and dates as dates. The Report sheet holds the stats
+// and errors of the transformation.
+type XLSXTransformer struct {
+	processor utils.PreProcessor
+	reporter  report.Reporter
+	options   Options
+}
+
+// NewXLSXTransformer creates a new instance of an xlsx transformer
+//
+// Accepts a reporter for reporting purposes and the options
+// of the run.
+func NewXLSXTransformer(reporter report.Reporter, opts Options) Transformer {
+	return &XLSXTransformer{
+		processor: utils.NewProcessor(),
+		reporter:  reporter,
+		options:   opts,
+	}
+}
+
+// ProcessRecord process records received via the chan
+func (tr *XLSXTransformer) ProcessRecord(wg *sync.WaitGroup, record <-chan utils.Row, done <-chan bool) {
+	var (
+		now    = time.Now()
+		wb     *xlsxWorkbook
+		failed bool
+		err    error
+	)
+
+	defer wg.Done()
+
+	// the workbook is created lazily as the parser
+	// sets the file's name & headers once it starts reading
+	open := func() {
+		wb, err = newXLSXWorkbook(tr.output())
+		if err != nil {
+			tr.reporter.AddError(err)
+			failed = true
+		}
+	}
+
+	// process pipeline
+	consume(tr.processor, tr.reporter, tr.options.Tolerances, record, done, nil, func(sr utils.SalesRecord) {
+		if wb == nil && !failed {
+			open()
+		}
+
+		if failed {
+			return
+		}
+
+		// stream row to the data sheet
+		if err := wb.Write(sr); err != nil {
+			tr.reporter.AddError(err)
+			failed = true
+		}
+	})
+
+	if wb == nil && !failed {
+		open()
+	}
+
+	// the report sheet holds the stats of the run
+	// so the workbook is saved once the transformation completes
+	finish(tr.reporter, now)
+
+	if wb != nil {
+		if err := wb.Close(tr.reporter); err != nil {
+			tr.reporter.AddError(err)
+		}
+	}
+
+	writeReport(tr.reporter)
+}
+
+// WriteOutputToFile write output data to file.
+func (tr *XLSXTransformer) WriteOutputToFile(output *Output) error {
+	wb, err := newXLSXWorkbook(output)
+	if err != nil {
+		return err
+	}
+
+	for _, sr := range output.Data {
+		if err = wb.Write(sr); err != nil {
+			wb.file.Close()
+			return err
+		}
+	}
+
+	return wb.Close(tr.reporter)
+}
+
+// output describes the workbook for the file being transformed
+func (tr *XLSXTransformer) output() *Output {
+	return &Output{
+		FileName:     filepath.Base(tr.reporter.GetFilename()),
+		TotalHeaders: len(tr.reporter.GetHeaders()),
+		Headers:      tr.reporter.GetHeaders(),
+	}
+}
+
+// xlsxWorkbook streams records to the data sheet of a workbook
+type xlsxWorkbook struct {
+	file        *excelize.File
+	sw          *excelize.StreamWriter
+	output      *Output
+	row         int
+	headerStyle int
+	amountStyle int
+	dateStyle   int
+}
+
+// newXLSXWorkbook creates a workbook and writes the headers
+// to its data sheet.
+func newXLSXWorkbook(output *Output) (*xlsxWorkbook, error) {
+	var err error
+
+	wb := &xlsxWorkbook{
+		file:   excelize.NewFile(),
+		output: output,
+	}
+
+	if err = wb.file.SetSheetName("Sheet1", xlsxDataSheet); err != nil {
+		return nil, err
+	}
+
+	// styles of the header, amount & date cells
+	wb.headerStyle, err = wb.file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return nil, err
+	}
+
+	wb.amountStyle, err = wb.file.NewStyle(&excelize.Style{NumFmt: 4}) // #,##0.00
+	if err != nil {
+		return nil, err
+	}
+
+	wb.dateStyle, err = wb.file.NewStyle(&excelize.Style{NumFmt: 14}) // m/d/yyyy
+	if err != nil {
+		return nil, err
+	}
+
+	wb.sw, err = wb.file.NewStreamWriter(xlsxDataSheet)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = wb.sw.SetColWidth(1, len(output.Headers)+1, 16); err != nil {
+		return nil, err
+	}
+
+	headers := make([]interface{}, len(output.Headers))
+	for i, h := range output.Headers {
+		headers[i] = excelize.Cell{StyleID: wb.headerStyle, Value: h}
+	}
+
+	wb.row = 1
+	if err = wb.sw.SetRow("A1", headers); err != nil {
+		return nil, err
+	}
+
+	return wb, nil
+}
+
+// Write writes a record as a row of the data sheet
+func (wb *xlsxWorkbook) Write(sr utils.SalesRecord) error {
+	wb.row++
+	wb.output.TotalRecords++
+
+	list := fields(sr)
+	cells := make([]interface{}, len(list))
+	for i, f := range list {
+		cells[i] = wb.cell(f)
+	}
+
+	cell, err := excelize.CoordinatesToCellName(1, wb.row)
+	if err != nil {
+		return err
+	}
+
+	return wb.sw.SetRow(cell, cells)
+}
+
+// cell types the value of a field going by its type
+//
+// Empty dates are written as empty text.
+func (wb *xlsxWorkbook) cell(f field) interface{} {
+	switch v := f.Typed.(type) {
+	case time.Time:
+		if !v.IsZero() {
+			return excelize.Cell{StyleID: wb.dateStyle, Value: v}
+		}
+	case decimal.Decimal:
+		return excelize.Cell{StyleID: wb.amountStyle, Value: v.InexactFloat64()}
+	case int64:
+		return v
+	}
+
+	return f.Value
+}
+
+// Close writes the report sheet and saves the workbook
+// to the output xlsx file.
+func (wb *xlsxWorkbook) Close(reporter report.Reporter) error {
+	defer wb.file.Close()
+
+	if err := wb.sw.Flush(); err != nil {
+		return err
+	}
+
+	if err := wb.writeReport(reporter); err != nil {
+		return err
+	}
+
+	// create output xlsx file
+	f, err := createOutputFile(wb.output.FileName, FormatXLSX)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = wb.file.WriteTo(f)
+	return err
+}
+
+// writeReport writes the stats and errors of the transformation
+// to the report sheet.
+func (wb *xlsxWorkbook) writeReport(reporter report.Reporter) error {
+	if _, err := wb.file.NewSheet(xlsxReportSheet); err != nil {
+		return err
+	}
+
+	rows := [][]interface{}{
+		{"File Name", wb.output.FileName},
+		{"Total Processed Records", reporter.GetTotalProcessedRecords()},
+		{"Total Failed Records", reporter.GetTotalFailedRecords()},
+		{"Total Transformed Records", reporter.GetTotalTransformedRecords()},
+		{"Duration", reporter.GetDuration()},
+		{"Completed At", reporter.GetCompletedAt()},
+		{},
+		{"Errors"},
+	}
+
+	for _, err := range reporter.GetErrors() {
+		rows = append(rows, []interface{}{err.Error()})
+	}
+
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return err
+		}
+
+		if err = wb.file.SetSheetRow(xlsxReportSheet, cell, &row); err != nil {
+			return err
+		}
+	}
+
+	if err := wb.file.SetColWidth(xlsxReportSheet, "A", "A", 28); err != nil {
+		return err
+	}
+
+	for _, cell := range []string{"A1", "A2", "A3", "A4", "A5", "A6", "A8"} {
+		if err := wb.file.SetCellStyle(xlsxReportSheet, cell, cell, wb.headerStyle); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package transform
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/parser"
+	"github.com/dele454/medium/csv-transform-to-html/internal/report"
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestXLSXProcessRecord(t *testing.T) {
+	reporter := report.NewMockReporter()
+	transformer := NewXLSXTransformer(reporter, Options{})
+
+	path := utils.RootDir()
+	p := parser.NewCSVParser(path+"/internal/testdata/100_sales_records.csv", reporter, parser.Dialect{})
+
+	// create waitgroup
+	wg := new(sync.WaitGroup)
+	wg.Add(2)
+
+	// channels for pipeline
+	record := make(chan utils.Row)
+	done := make(chan bool)
+
+	go transformer.ProcessRecord(wg, record, done)
+	go p.Read(wg, record, done)
+
+	wg.Wait()
+
+	count := len(reporter.GetErrors())
+	expected := 0
+	if count != expected {
+		t.Fatalf("Expected %d, got %d", expected, count)
+	}
+
+	f, err := excelize.OpenFile(utils.OutputDir() + "/100_sales_records.csv.xlsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	rows, err := f.GetRows(xlsxDataSheet, excelize.Options{RawCellValue: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// headers plus a row per record
+	count = len(rows)
+	expected = 101
+	if count != expected {
+		t.Fatalf("Expected %d, got %d", expected, count)
+	}
+
+	// Australia and Oceania,Tuvalu,Baby Food,Offline,H,5/28/2010,669165933,6/27/2010,9925,255.28,...
+	first := rows[1]
+	if first[1] != "Tuvalu" {
+		t.Fatalf("Expected text cell 'Tuvalu', got '%s'", first[1])
+	}
+
+	// dates are stored as excel serial dates
+	if first[5] != "40326" {
+		t.Fatalf("Expected date cell 40326, got '%s'", first[5])
+	}
+
+	for _, i := range []int{6, 8, 9} {
+		if _, err := strconv.ParseFloat(first[i], 64); err != nil {
+			t.Fatalf("Expected numeric cell for %s, got '%s'", rows[0][i], first[i])
+		}
+	}
+
+	typ, err := f.GetCellType(xlsxDataSheet, "B2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ == excelize.CellTypeUnset || typ == excelize.CellTypeNumber {
+		t.Fatal("Text fields should be written as text cells")
+	}
+
+	v, err := f.GetCellValue(xlsxReportSheet, "B4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "100" {
+		t.Fatalf("Expected 100 transformed records on the report sheet, got '%s'", v)
+	}
+}
@@ -0,0 +1,140 @@
+package transform
+
+import (
+	"bufio"
+	"encoding/xml"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/report"
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
+)
+
+// XMLTransformer handles the processing of customer data
+// with the aid of preprocessors and writing the output
+// as an XML document.
+//
+// The document is laid out as a single SalesRecords root element
+// holding one SalesRecord element per transformed row. Every field
+// of a row is written as a child element named after its csv tag:
+//
+//	<SalesRecords file="sales.csv" total="1">
+//	  <SalesRecord>
+//	    <Region>Australia and Oceania</Region>
+//	    <Country>Tuvalu</Country>
+//	    ...
+//	  </SalesRecord>
+//	</SalesRecords>
+type XMLTransformer struct {
+	processor utils.PreProcessor
+	reporter  report.Reporter
+	options   Options
+}
+
+// NewXMLTransformer creates a new instance of an xml transformer
+//
+// Accepts a reporter for reporting purposes and the options
+// of the run.
+func NewXMLTransformer(reporter report.Reporter, opts Options) Transformer {
+	return &XMLTransformer{
+		processor: utils.NewProcessor(),
+		reporter:  reporter,
+		options:   opts,
+	}
+}
+
+// ProcessRecord process records received via the chan
+func (tr *XMLTransformer) ProcessRecord(wg *sync.WaitGroup, record <-chan utils.Row, done <-chan bool) {
+	var (
+		now  = time.Now()
+		data []utils.SalesRecord
+	)
+
+	defer func() {
+		complete(tr.reporter, now)
+		wg.Done()
+	}()
+
+	// process pipeline
+	consume(tr.processor, tr.reporter, tr.options.Tolerances, record, done, nil, func(sr utils.SalesRecord) {
+		data = append(data, sr)
+	})
+
+	// send output to file
+	err := tr.WriteOutputToFile(&Output{
+		FileName:     filepath.Base(tr.reporter.GetFilename()),
+		TotalHeaders: len(tr.reporter.GetHeaders()),
+		Headers:      tr.reporter.GetHeaders(),
+		Data:         data,
+	})
+	if err != nil {
+		tr.reporter.AddError(err)
+	}
+}
+
+// WriteOutputToFile write output data to file.
+func (tr *XMLTransformer) WriteOutputToFile(output *Output) error {
+	// create output xml file
+	f, err := createOutputFile(output.FileName, "xml")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	if _, err = w.WriteString(xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	root := xml.StartElement{
+		Name: xml.Name{Local: "SalesRecords"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "file"}, Value: output.FileName},
+			{Name: xml.Name{Local: "total"}, Value: strconv.Itoa(len(output.Data))},
+		},
+	}
+	if err = enc.EncodeToken(root); err != nil {
+		return err
+	}
+
+	// write an element per record
+	for _, sr := range output.Data {
+		if err = encodeXMLRecord(enc, sr); err != nil {
+			return err
+		}
+	}
+
+	if err = enc.EncodeToken(root.End()); err != nil {
+		return err
+	}
+
+	if err = enc.Flush(); err != nil {
+		return err
+	}
+
+	// flush buffer
+	return w.Flush()
+}
+
+// encodeXMLRecord writes a SalesRecord element with a child element
+// per field named after the field's csv tag.
+func encodeXMLRecord(enc *xml.Encoder, sr utils.SalesRecord) error {
+	start := xml.StartElement{Name: xml.Name{Local: "SalesRecord"}}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	for _, f := range fields(sr) {
+		err := enc.EncodeElement(f.Value, xml.StartElement{Name: xml.Name{Local: f.Name}})
+		if err != nil {
+			return err
+		}
+	}
+
+	return enc.EncodeToken(start.End())
+}
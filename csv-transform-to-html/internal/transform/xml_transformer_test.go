@@ -0,0 +1,78 @@
+package transform
+
+import (
+	"encoding/xml"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/parser"
+	"github.com/dele454/medium/csv-transform-to-html/internal/report"
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
+)
+
+func TestXMLProcessRecord(t *testing.T) {
+	reporter := report.NewMockReporter()
+	transformer := NewXMLTransformer(reporter, Options{})
+
+	path := utils.RootDir()
+	p := parser.NewCSVParser(path+"/internal/testdata/100_sales_records.csv", reporter, parser.Dialect{})
+
+	// create waitgroup
+	wg := new(sync.WaitGroup)
+	wg.Add(2)
+
+	// channels for pipeline
+	record := make(chan utils.Row)
+	done := make(chan bool)
+
+	go transformer.ProcessRecord(wg, record, done)
+	go p.Read(wg, record, done)
+
+	wg.Wait()
+
+	count := len(reporter.GetErrors())
+	expected := 0
+	if count != expected {
+		t.Fatalf("Expected %d, got %d", expected, count)
+	}
+
+	b, err := os.ReadFile(utils.OutputDir() + "/100_sales_records.csv.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Records []struct {
+			Region  string `xml:"Region"`
+			OrderID string `xml:"OrderID"`
+		} `xml:"SalesRecord"`
+	}
+	if err := xml.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	count = len(doc.Records)
+	expected = 100
+	if count != expected {
+		t.Fatalf("Expected %d, got %d", expected, count)
+	}
+
+	if doc.Records[0].Region != "Australia and Oceania" || doc.Records[0].OrderID != "669165933" {
+		t.Fatalf("Unexpected first record: %+v", doc.Records[0])
+	}
+}
+
+func TestNewTransformer(t *testing.T) {
+	reporter := report.NewMockReporter()
+
+	for _, format := range []string{"", FormatHTML, FormatXML, FormatJSON, FormatNDJSON, FormatXLSX, FormatMarkdown, FormatTXT} {
+		if _, err := NewTransformer(format, reporter, Options{}); err != nil {
+			t.Fatalf("Format '%s' should be supported: %v", format, err)
+		}
+	}
+
+	if _, err := NewTransformer("pdf", reporter, Options{}); err == nil {
+		t.Fatal("Format 'pdf' should not be supported")
+	}
+}
@@ -0,0 +1,23 @@
+package utils
+
+// DefaultOutputDir folder the outputs are written to by default,
+// relative to the working directory
+const DefaultOutputDir = "output"
+
+// outputDir folder the outputs are written to
+var outputDir = DefaultOutputDir
+
+// SetOutputDir sets the folder the outputs, rejected files and
+// checkpoints are written to, the default folder if empty
+func SetOutputDir(d string) {
+	if d == "" {
+		d = DefaultOutputDir
+	}
+
+	outputDir = d
+}
+
+// OutputDir returns the folder the outputs are written to
+func OutputDir() string {
+	return outputDir
+}
@@ -6,42 +6,83 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/dele454/medium/csv-transform-to-html/internal/errs"
+	"github.com/shopspring/decimal"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 )
 
+// DateLayout layout of the dates of a source file
+const DateLayout = "1/2/2006"
+
+// FormatDate formats a date the way dates of a source file are laid out
+func FormatDate(d time.Time) string {
+	if d.IsZero() {
+		return ""
+	}
+
+	return d.Format(DateLayout)
+}
+
+// FormatAmount formats an amount with as many decimal places as it
+// was read with, e.g 2533654.00 is kept as is
+func FormatAmount(d decimal.Decimal) string {
+	if e := d.Exponent(); e < 0 {
+		return d.StringFixed(-e)
+	}
+
+	return d.String()
+}
+
 // SalesRecord details data about a sale record
 type SalesRecord struct {
-	Region        string `csv:"Region"`
-	Country       string `csv:"Country" processor:"required"`
-	ItemType      string `csv:"ItemType" processor:"required"`
-	SalesChannel  string `csv:"SalesChannel"`
-	OrderPriority string `csv:"OrderPriority"`
-	OrderDate     string `csv:"OrderDate" processor:"date,required"`
-	OrderID       string `csv:"OrderID" processor:"numeric,required"`
-	ShipDate      string `csv:"ShipDate" processor:"date,required"`
-	UnitsSold     string `csv:"UnitsSold" processor:"numeric,required"`
-	UnitPrice     string `csv:"UnitPrice" processor:"amount,required"`
-	UnitCost      string `csv:"UnitCost" processor:"amount,required"`
-	TotalRevenue  string `csv:"TotalRevenue" processor:"amount"`
-	TotalCost     string `csv:"TotalCost" processor:"amount,required"`
-	TotalProfit   string `csv:"TotalProfit" processor:"amount,required"`
+	Region        string          `csv:"Region"`
+	Country       string          `csv:"Country" processor:"required"`
+	ItemType      string          `csv:"ItemType" processor:"required"`
+	SalesChannel  string          `csv:"SalesChannel" processor:"enum=Online|Offline"`
+	OrderPriority string          `csv:"OrderPriority" processor:"enum=H|M|L|C"`
+	OrderDate     time.Time       `csv:"OrderDate" processor:"date,required"`
+	OrderID       int64           `csv:"OrderID" processor:"numeric,required,regex=^[1-9][0-9]{8}$"`
+	ShipDate      time.Time       `csv:"ShipDate" processor:"date,required"`
+	UnitsSold     int64           `csv:"UnitsSold" processor:"numeric,required,min=1"`
+	UnitPrice     decimal.Decimal `csv:"UnitPrice" processor:"amount,required"`
+	UnitCost      decimal.Decimal `csv:"UnitCost" processor:"amount,required"`
+	TotalRevenue  decimal.Decimal `csv:"TotalRevenue" processor:"amount"`
+	TotalCost     decimal.Decimal `csv:"TotalCost" processor:"amount,required"`
+	TotalProfit   decimal.Decimal `csv:"TotalProfit" processor:"amount,required"`
 }
 
+// patterns compiled patterns of the regex= tags
+var patterns sync.Map
+
+// checked outcome of the check of the processor tags of each type
+var checked sync.Map
+
+// types of the fields parsed into something other than a string
+var (
+	dateType   = reflect.TypeOf(time.Time{})
+	amountType = reflect.TypeOf(decimal.Decimal{})
+)
+
 // Preprocessor operations a transformer must perform
 type PreProcessor interface {
 	EscapeHTML(val string) string
 	NotEmpty(val, field string) error
 	SanitizeString(str string) string
-	ParseDate(val, field string) error
-	ParseFloat(val, field string) error
-	ParseInteger(val, field string) error
+	ParseDate(val, field string) (time.Time, error)
+	ParseFloat(val, field string) (float64, error)
+	ParseAmount(val, field string) (decimal.Decimal, error)
+	ParseInteger(val, field string) (int64, error)
+	ParseEnum(val, field string, values []string) (string, error)
+	Validate(val, field, rule string) error
 
 	Unmarshal(record []string, sr SalesRecord) (SalesRecord, error)
 }
@@ -73,95 +114,473 @@ func (p *Processor) NotEmpty(val, field string) error {
 	return nil
 }
 
-// ParseDate parses a date string in a DD/MM/YYYY format
-func (p *Processor) ParseDate(val, field string) error {
-	var err error
+// ParseDate parses a date string in a MM/DD/YYYY format
+func (p *Processor) ParseDate(val, field string) (time.Time, error) {
+	return p.parseDate(val, field, DateLayout)
+}
 
+// parseDate parses a date string in the given layout
+func (p *Processor) parseDate(val, field, layout string) (time.Time, error) {
 	if err := p.NotEmpty(val, field); err != nil {
-		return err
+		return time.Time{}, err
 	}
 
 	// parse date field
-	_, err = time.Parse("1/2/2006", val)
+	d, err := time.Parse(layout, val)
 	if err != nil {
-		return fmt.Errorf(errs.ErrorFieldNotValid.Error(), field)
+		return time.Time{}, fmt.Errorf(errs.ErrorFieldNotValid.Error(), field)
 	}
 
-	return nil
+	return d, nil
 }
 
 // ParseFloat parse a float value
-func (p *Processor) ParseFloat(val, field string) error {
+func (p *Processor) ParseFloat(val, field string) (float64, error) {
 	if err := p.NotEmpty(val, field); err != nil {
-		return err
+		return 0, err
 	}
 
-	_, err := strconv.ParseFloat(val, 64)
+	f, err := strconv.ParseFloat(val, 64)
 	if err != nil {
-		return fmt.Errorf(errs.ErrorFieldNotValid.Error(), field)
+		return 0, fmt.Errorf(errs.ErrorFieldNotValid.Error(), field)
 	}
 
-	return nil
+	return f, nil
+}
+
+// ParseAmount parse an amount into an exact decimal
+func (p *Processor) ParseAmount(val, field string) (decimal.Decimal, error) {
+	if err := p.NotEmpty(val, field); err != nil {
+		return decimal.Decimal{}, err
+	}
+
+	d, err := decimal.NewFromString(val)
+	if err != nil {
+		return decimal.Decimal{}, fmt.Errorf(errs.ErrorFieldNotValid.Error(), field)
+	}
+
+	return d, nil
 }
 
 // ParseInteger parse a int value
-func (p *Processor) ParseInteger(val, field string) error {
+func (p *Processor) ParseInteger(val, field string) (int64, error) {
 	if err := p.NotEmpty(val, field); err != nil {
-		return err
+		return 0, err
 	}
 
-	_, err := strconv.ParseInt(val, 0, 36)
+	n, err := strconv.ParseInt(val, 10, 64)
 	if err != nil {
-		return fmt.Errorf(errs.ErrorFieldNotValid.Error(), field)
+		return 0, fmt.Errorf(errs.ErrorFieldNotValid.Error(), field)
+	}
+
+	return n, nil
+}
+
+// ParseEnum matches a value against the values a field can take,
+// ignoring case, returning the value as declared
+//
+// e.g " online" is read as Online for enum=Online|Offline
+//
+// Empty values pass, required being the tag refusing them.
+func (p *Processor) ParseEnum(val, field string, values []string) (string, error) {
+	val = p.SanitizeString(val)
+	if val == "" {
+		return "", nil
+	}
+
+	for _, v := range values {
+		if strings.EqualFold(val, v) {
+			return v, nil
+		}
+	}
+
+	return "", fmt.Errorf(errs.ErrorFieldNotInEnum.Error(), field, strings.Join(values, ", "))
+}
+
+// Validate checks a value against a rule of a processor tag, i.e
+// min=, max=, len=, maxlen= or regex=
+//
+// Empty values pass, required being the tag refusing them.
+func (p *Processor) Validate(val, field, rule string) error {
+	val = p.SanitizeString(val)
+	if val == "" {
+		return nil
+	}
+
+	name, arg, _ := strings.Cut(rule, "=")
+	switch name {
+	case "min", "max":
+		return p.bound(val, field, name, arg)
+	case "len", "maxlen":
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			return fmt.Errorf(errs.ErrorInvalidTag.Error(), rule, field)
+		}
+
+		l := utf8.RuneCountInString(val)
+		if name == "len" && l != n {
+			return fmt.Errorf(errs.ErrorFieldLength.Error(), field, n)
+		}
+		if name == "maxlen" && l > n {
+			return fmt.Errorf(errs.ErrorFieldTooLong.Error(), field, n)
+		}
+	case "regex":
+		re, err := pattern(arg)
+		if err != nil {
+			return fmt.Errorf(errs.ErrorInvalidTag.Error(), rule, field)
+		}
+
+		if !re.MatchString(val) {
+			return fmt.Errorf(errs.ErrorFieldPattern.Error(), field, arg)
+		}
+	default:
+		return fmt.Errorf(errs.ErrorInvalidTag.Error(), rule, field)
 	}
 
 	return nil
 }
 
-// Unmarshal unmarshals records found into the SalesRecord struct
+// bound checks a value against the min or max bound of a rule,
+// comparing numbers, or dates for a bound laid out as a date.
+func (p *Processor) bound(val, field, name, arg string) error {
+	var cmp int
+
+	if b, err := decimal.NewFromString(arg); err == nil {
+		d, err := decimal.NewFromString(val)
+		if err != nil {
+			return fmt.Errorf(errs.ErrorFieldNotValid.Error(), field)
+		}
+		cmp = d.Cmp(b)
+	} else if b, err := time.Parse(DateLayout, arg); err == nil {
+		d, err := time.Parse(DateLayout, val)
+		if err != nil {
+			return fmt.Errorf(errs.ErrorFieldNotValid.Error(), field)
+		}
+
+		switch {
+		case d.Before(b):
+			cmp = -1
+		case d.After(b):
+			cmp = 1
+		}
+	} else {
+		return fmt.Errorf(errs.ErrorInvalidTag.Error(), name+"="+arg, field)
+	}
+
+	if name == "min" && cmp < 0 {
+		return fmt.Errorf(errs.ErrorFieldBelowMin.Error(), field, arg)
+	}
+	if name == "max" && cmp > 0 {
+		return fmt.Errorf(errs.ErrorFieldAboveMax.Error(), field, arg)
+	}
+
+	return nil
+}
+
+// pattern compiles the pattern of a regex= tag once
+func pattern(expr string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	patterns.Store(expr, re)
+	return re, nil
+}
+
+// SplitTags splits the processor tags of a field
 //
-// Cycles through all the fields for the SalesRecord struct in order
-// to decipher which field(s) needs a pre-processor and apply
-// as record is unmarshalled.
-func (p *Processor) Unmarshal(record []string, sr SalesRecord) (SalesRecord, error) {
-	s := reflect.ValueOf(sr).Type()
+// A regex= tag takes the rest of the tags as its pattern,
+// commas included, so must come last.
+func SplitTags(tag string) []string {
+	var tags []string
+	for tag != "" {
+		if strings.HasPrefix(tag, "regex=") {
+			return append(tags, tag)
+		}
+
+		t, rest, _ := strings.Cut(tag, ",")
+		tags = append(tags, t)
+		tag = rest
+	}
+
+	return tags
+}
+
+// CheckTags checks the processor tags of the fields of a struct of
+// any type, so a struct with a bad tag fails up front rather than
+// rejecting every row.
+//
+// Tags must be known, take a valid argument and suit the type of
+// their field. The outcome is kept for each type.
+func CheckTags[T any]() error {
+	return checkTags(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// checkTags checks the processor tags of a struct once
+func checkTags(s reflect.Type) error {
+	if err, ok := checked.Load(s); ok {
+		err, _ := err.(error)
+		return err
+	}
+
+	err := tagsError(s)
+	checked.Store(s, err)
+
+	return err
+}
+
+// tagsError finds the first bad processor tag of a struct, if any
+func tagsError(s reflect.Type) error {
+	if s.Kind() != reflect.Struct {
+		return &UnsupportedType{Type: s.String()}
+	}
+
 	for i := 0; i < s.NumField(); i++ {
 		field := s.Field(i)
-		tags := strings.Split(field.Tag.Get("processor"), ",")
+		if !mapped(field) {
+			continue
+		}
+
+		if !supported(field.Type) {
+			return &UnsupportedType{Type: field.Type.String()}
+		}
 
-		for _, t := range tags {
-			switch t {
+		text := field.Type.Kind() == reflect.String
+		for _, t := range SplitTags(field.Tag.Get("processor")) {
+			name, arg, _ := strings.Cut(t, "=")
+
+			var ok bool
+			switch name {
 			case "required":
-				err := p.NotEmpty(record[i], field.Name)
+				ok = arg == ""
+			case "date":
+				ok = text || field.Type == dateType
+			case "amount":
+				ok = arg == "" && (text || field.Type == amountType)
+			case "numeric":
+				ok = arg == "" && (text || integer(field.Type))
+			case "enum":
+				ok = arg != "" && text
+			case "min", "max":
+				_, err := decimal.NewFromString(arg)
 				if err != nil {
-					return sr, err
+					_, err = time.Parse(DateLayout, arg)
 				}
-				reflect.ValueOf(&sr).Elem().Field(i).SetString(p.EscapeHTML(p.SanitizeString(record[i])))
+				ok = err == nil
+			case "len", "maxlen":
+				n, err := strconv.Atoi(arg)
+				ok = err == nil && n >= 0
+			case "regex":
+				_, err := pattern(arg)
+				ok = err == nil
+			}
+
+			if !ok {
+				return fmt.Errorf(errs.ErrorInvalidTag.Error(), t, field.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Unmarshal unmarshals records found into the SalesRecord struct
+//
+// Values of the record are expected in the order of the struct
+// fields, see HeaderMapping.
+func (p *Processor) Unmarshal(record []string, sr SalesRecord) (SalesRecord, error) {
+	err := p.unmarshal(record, reflect.ValueOf(&sr).Elem())
+	return sr, err
+}
+
+// Unmarshal unmarshals a record into a struct of any type, applying
+// the csv & processor tags of its fields the way they are applied to
+// the SalesRecord.
+//
+// Values of the record are expected in the order of the struct
+// fields, see MapHeadersTo. Fields are either strings, integers,
+// time.Time dates or decimal.Decimal amounts.
+func Unmarshal[T any](record []string) (T, error) {
+	var v T
+	err := (&Processor{}).unmarshal(record, reflect.ValueOf(&v).Elem())
+	return v, err
+}
+
+// unmarshal unmarshals a record into the given struct
+//
+// Cycles through all the fields of the struct in order to decipher
+// which field(s) needs a pre-processor and apply as record is
+// unmarshalled. Values missing from the record are left empty.
+//
+// Unexported fields and fields tagged csv:"-" are skipped, the
+// record holding a value per mapped field only.
+func (p *Processor) unmarshal(record []string, v reflect.Value) error {
+	s := v.Type()
+	if err := checkTags(s); err != nil {
+		return err
+	}
+
+	i := -1
+	for n := 0; n < s.NumField(); n++ {
+		field := s.Field(n)
+		if !mapped(field) {
+			continue
+		}
+		i++
+
+		var value string
+		if i < len(record) {
+			value = record[i]
+		}
+
+		var (
+			// value parsed by the tags, if any
+			parsed interface{}
+			// rules checked once the value is parsed
+			rules []string
+		)
+
+		for _, t := range SplitTags(field.Tag.Get("processor")) {
+			var err error
+
+			// tags such as enum= take an argument
+			name, arg, _ := strings.Cut(t, "=")
+
+			switch name {
+			case "min", "max", "len", "maxlen", "regex":
+				rules = append(rules, t)
+			case "enum":
+				parsed, err = p.ParseEnum(value, field.Name, strings.Split(arg, "|"))
+			case "required":
+				err = p.NotEmpty(value, field.Name)
 			case "date":
-				err := p.ParseDate(record[i], field.Name)
-				if err != nil {
-					return sr, err
+				// dates are laid out as 1/2/2006 unless given
+				// a layout, e.g date=2006-01-02
+				layout := DateLayout
+				if arg != "" {
+					layout = arg
 				}
-				reflect.ValueOf(&sr).Elem().Field(i).SetString(record[i])
+				parsed, err = p.parseDate(value, field.Name, layout)
 			case "amount":
-				err := p.ParseFloat(record[i], field.Name)
-				if err != nil {
-					return sr, err
-				}
-				reflect.ValueOf(&sr).Elem().Field(i).SetString(record[i])
+				parsed, err = p.ParseAmount(value, field.Name)
 			case "numeric":
-				err := p.ParseInteger(record[i], field.Name)
-				if err != nil {
-					return sr, err
-				}
-				reflect.ValueOf(&sr).Elem().Field(i).SetString(record[i])
-			default:
-				reflect.ValueOf(&sr).Elem().Field(i).SetString(p.EscapeHTML(p.SanitizeString(record[i])))
+				parsed, err = p.ParseInteger(value, field.Name)
 			}
+
+			if err != nil {
+				return fieldError(i, field.Name, value, err)
+			}
+		}
+
+		for _, r := range rules {
+			if err := p.Validate(value, field.Name, r); err != nil {
+				return fieldError(i, field.Name, value, err)
+			}
+		}
+
+		if err := p.set(v.Field(n), field.Name, value, parsed); err != nil {
+			return fieldError(i, field.Name, value, err)
+		}
+	}
+
+	return nil
+}
+
+// mapped reports if a field of a struct is mapped to a column,
+// i.e it is exported and not tagged csv:"-"
+func mapped(field reflect.StructField) bool {
+	return field.IsExported() && field.Tag.Get("csv") != "-"
+}
+
+// supported reports if a field of the given type can be unmarshalled
+func supported(t reflect.Type) bool {
+	return t.Kind() == reflect.String || integer(t) || t == dateType || t == amountType
+}
+
+// integer reports if the type is an integer of any size
+func integer(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+
+	return false
+}
+
+// set sets a field to the value parsed by its tags, parsing the
+// value going by the type of the field otherwise.
+//
+// Text is sanitized and escaped, values parsed by a tag are kept
+// as is unless parsed into text, such as the declared spelling of
+// an enum. Empty values of dates, integers & amounts are left zero.
+func (p *Processor) set(f reflect.Value, name, value string, parsed interface{}) error {
+	var err error
+
+	if f.Kind() == reflect.String {
+		switch v := parsed.(type) {
+		case nil:
+			value = p.EscapeHTML(p.SanitizeString(value))
+		case string:
+			value = p.EscapeHTML(v)
+		}
+
+		f.SetString(value)
+		return nil
+	}
+
+	if parsed == nil {
+		if value == "" {
+			return nil
+		}
+
+		switch f.Type() {
+		case dateType:
+			parsed, err = p.ParseDate(value, name)
+		case amountType:
+			parsed, err = p.ParseAmount(value, name)
+		default:
+			parsed, err = p.ParseInteger(value, name)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	// integers of any size
+	if n, ok := parsed.(int64); ok && f.CanInt() {
+		if f.OverflowInt(n) {
+			return fmt.Errorf(errs.ErrorFieldNotValid.Error(), name)
 		}
+
+		f.SetInt(n)
+		return nil
+	}
+
+	// a tag parsing a type other than the field's
+	pv := reflect.ValueOf(parsed)
+	if pv.Type() != f.Type() {
+		return &UnsupportedType{Type: f.Type().String()}
 	}
 
-	return sr, nil
+	f.Set(pv)
+	return nil
+}
+
+// fieldError wraps an error found in the value of a field
+// of a record with the details of the field.
+func fieldError(index int, name, value string, err error) error {
+	return &errs.FieldError{
+		Index: index,
+		Field: name,
+		Value: value,
+		Err:   err,
+	}
 }
 
 // UnsupportedType
@@ -175,17 +594,28 @@ func (e *UnsupportedType) Error() string {
 }
 
 // GetHeaders gets all expected headers for the SalesOrder struct
-//
-// Introspects all csv tags for the SalesOrder struct fields
-// and builds a list of headers from that thus making it dynamic
 func GetHeaders() []string {
-	var (
-		headers []string
-		sr      SalesRecord
-	)
+	return Headers[SalesRecord]()
+}
+
+// Headers gets all expected headers for a struct of any type
+//
+// Introspects all csv tags for the struct fields and builds
+// a list of headers from that thus making it dynamic. Unexported
+// fields and fields tagged csv:"-" are left out.
+func Headers[T any]() []string {
+	var headers []string
+
+	s := reflect.TypeOf((*T)(nil)).Elem()
+	if s.Kind() != reflect.Struct {
+		return nil
+	}
 
-	s := reflect.ValueOf(sr).Type()
 	for i := 0; i < s.NumField(); i++ {
+		if !mapped(s.Field(i)) {
+			continue
+		}
+
 		headers = append(headers, cases.Title(language.Und, cases.NoLower).
 			String(s.Field(i).Tag.Get("csv")))
 	}
@@ -193,6 +623,94 @@ func GetHeaders() []string {
 	return headers
 }
 
+// HeaderMapping maps every SalesRecord field, or those of any
+// struct unmarshalled with Unmarshal, in struct order,
+// to the index of the column holding it in a source file.
+type HeaderMapping []int
+
+// MapHeaders builds the mapping for the headers of a source file
+//
+// Every expected header must appear exactly once in the file
+// though in any order.
+func MapHeaders(headers []string) (HeaderMapping, error) {
+	return MapHeadersTo[SalesRecord](headers)
+}
+
+// MapHeadersTo builds the mapping of the headers of a source file
+// to the fields of a struct of any type, once its processor tags
+// are checked
+func MapHeadersTo[T any](headers []string) (HeaderMapping, error) {
+	// rows cannot be unmarshalled with bad tags
+	if err := CheckTags[T](); err != nil {
+		return nil, err
+	}
+
+	expected := Headers[T]()
+
+	// check for expected nos of headers
+	if len(expected) != len(headers) {
+		return nil, errs.ErrorUnmatachedHeaders
+	}
+
+	columns := make(map[string]int, len(headers))
+	for i, h := range headers {
+		h = strings.TrimSpace(h)
+		if _, ok := columns[h]; ok {
+			return nil, fmt.Errorf(errs.ErrorDuplicateHeader.Error(), h)
+		}
+		columns[h] = i
+	}
+
+	// check if all expected headers are in source file
+	mapping := make(HeaderMapping, len(expected))
+	for i, h := range expected {
+		col, ok := columns[h]
+		if !ok {
+			return nil, fmt.Errorf(errs.ErrorHeaderNotFound.Error(), h)
+		}
+		mapping[i] = col
+	}
+
+	return mapping, nil
+}
+
+// Row a row read from a source file along with its position
+type Row struct {
+	// Line line of the source file the row starts on
+	Line int
+	// Values values of the row in the order of the SalesRecord fields
+	Values []string
+	// Raw values of the row as read from the source file
+	Raw []string
+	// Text text of the row as read from the source file, kept
+	// for rows which failed to be split into values
+	Text string
+	// Columns index of the column of the source file
+	// each value was read from
+	Columns []int
+	// Err error the parser failed to read the row with, if any.
+	// The row has already been reported as failed.
+	Err error
+	// EndLine last line of the source file the row spans
+	EndLine int
+	// Offset byte offset of the source file past the row, where
+	// reading resumes from. Zero if the parser cannot resume.
+	Offset int64
+}
+
+// Apply orders the values of a row read from the source
+// file the way the SalesRecord fields are ordered.
+func (m HeaderMapping) Apply(row []string) []string {
+	ordered := make([]string, len(m))
+	for i, col := range m {
+		if col < len(row) {
+			ordered[i] = row[col]
+		}
+	}
+
+	return ordered
+}
+
 // RootDir returns the root directory for the application
 func RootDir() string {
 	_, b, _, _ := runtime.Caller(0)
@@ -1,23 +1,33 @@
 package utils
 
 import (
+	"errors"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/dele454/medium/csv-transform-to-html/internal/errs"
+	"github.com/shopspring/decimal"
 )
 
 func TestParseDate(t *testing.T) {
 	var p Processor
 
-	err := p.ParseDate("6/27/2010", "ShipDate")
+	d, err := p.ParseDate("6/27/2010", "ShipDate")
 	if err != nil {
 		t.Fatalf("Date should be valid")
 	}
 
-	err = p.ParseDate("27/01/2010", "ShipDate")
+	if !d.Equal(time.Date(2010, 6, 27, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Expected 2010-06-27, got %v", d)
+	}
+
+	_, err = p.ParseDate("27/01/2010", "ShipDate")
 	if err == nil {
 		t.Fatal("Date should not be valid")
 	}
 
-	err = p.ParseDate("", "ShipDate")
+	_, err = p.ParseDate("", "ShipDate")
 	if err == nil {
 		t.Fatal("Date should not be valid")
 	}
@@ -26,22 +36,194 @@ func TestParseDate(t *testing.T) {
 func TestParseFloat(t *testing.T) {
 	var p Processor
 
-	err := p.ParseFloat("ParseBirthday", "Credit Limit")
+	_, err := p.ParseFloat("ParseBirthday", "Credit Limit")
 	if err == nil {
 		t.Fatal("Credit limit should not be valid")
 	}
 
-	err = p.ParseFloat("121.99", "Credit Limit")
+	_, err = p.ParseFloat("121.99", "Credit Limit")
 	if err != nil {
 		t.Fatal("Credit limit should be valid")
 	}
 
-	err = p.ParseFloat("1000000", "Credit Limit")
+	_, err = p.ParseFloat("1000000", "Credit Limit")
 	if err != nil {
 		t.Fatal("Credit limit should be valid")
 	}
 }
 
+func TestParseAmount(t *testing.T) {
+	var p Processor
+
+	if _, err := p.ParseAmount("SAY WHAT", "TotalProfit"); err == nil {
+		t.Fatal("Amount should not be valid")
+	}
+
+	// amounts are exact
+	a, err := p.ParseAmount("0.10", "UnitPrice")
+	if err != nil {
+		t.Fatal("Amount should be valid")
+	}
+
+	if !a.Add(decimal.RequireFromString("0.20")).Equal(decimal.RequireFromString("0.3")) {
+		t.Fatalf("Expected 0.3, got %v", a.Add(decimal.RequireFromString("0.20")))
+	}
+}
+
+func TestParseInteger(t *testing.T) {
+	var p Processor
+
+	n, err := p.ParseInteger("669165933", "OrderID")
+	if err != nil || n != 669165933 {
+		t.Fatalf("Expected 669165933, got %d, %v", n, err)
+	}
+
+	// leading zeros aren't read as octal
+	if n, _ = p.ParseInteger("010", "UnitsSold"); n != 10 {
+		t.Fatalf("Expected 10, got %d", n)
+	}
+
+	if _, err = p.ParseInteger("12x4", "OrderID"); err == nil {
+		t.Fatal("Integer should not be valid")
+	}
+}
+
+func TestParseEnum(t *testing.T) {
+	var p Processor
+
+	values := []string{"Online", "Offline"}
+
+	for _, v := range []string{"Online", "online ", "\tOFFLINE"} {
+		got, err := p.ParseEnum(v, "SalesChannel", values)
+		if err != nil || !strings.EqualFold(got, strings.TrimSpace(v)) {
+			t.Fatalf("Expected %q to match, got %q, %v", v, got, err)
+		}
+	}
+
+	// values are canonicalised to the declared spelling
+	if got, _ := p.ParseEnum("offline", "SalesChannel", values); got != "Offline" {
+		t.Fatalf("Expected Offline, got %q", got)
+	}
+
+	_, err := p.ParseEnum("Ofline", "SalesChannel", values)
+	if err == nil || err.Error() != "'SalesChannel' Field must be one of Online, Offline." {
+		t.Fatalf("Expected Ofline to be refused, got %v", err)
+	}
+
+	// empty values are left to the required tag
+	if got, err := p.ParseEnum(" ", "SalesChannel", values); err != nil || got != "" {
+		t.Fatalf("Expected an empty value to pass, got %q, %v", got, err)
+	}
+
+	type order struct {
+		Channel  string `csv:"Channel" processor:"enum=Online|Offline"`
+		Priority string `csv:"Priority" processor:"required,enum=H|M|L|C"`
+	}
+
+	o, err := Unmarshal[order]([]string{"", "h"})
+	if err != nil || o.Channel != "" || o.Priority != "H" {
+		t.Fatalf("Expected an empty channel to pass, got %+v, %v", o, err)
+	}
+
+	if _, err = Unmarshal[order]([]string{"Online", ""}); err == nil {
+		t.Fatal("Expected an empty required enum to be refused")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	var p Processor
+
+	tests := []struct {
+		val  string
+		rule string
+		err  string
+	}{
+		{"1", "min=1", ""},
+		{"0", "min=1", "'UnitsSold' Field must be at least 1."},
+		{"-5", "min=1", "'UnitsSold' Field must be at least 1."},
+		{"255.28", "max=255.28", ""},
+		{"255.29", "max=255.28", "'UnitsSold' Field must be at most 255.28."},
+		{"abc", "min=1", "'UnitsSold' Field is not valid."},
+		{"6/27/2010", "min=1/1/2010", ""},
+		{"6/27/2009", "min=1/1/2010", "'UnitsSold' Field must be at least 1/1/2010."},
+		{"669165933", "len=9", ""},
+		{"66916593", "len=9", "'UnitsSold' Field must be 9 characters long."},
+		{"Tuvalu", "maxlen=6", ""},
+		{"Grenada", "maxlen=6", "'UnitsSold' Field must be at most 6 characters long."},
+		{"669165933", "regex=^[0-9]{9}$", ""},
+		{"+66916593", "regex=^[0-9]{9}$", "'UnitsSold' Field must match ^[0-9]{9}$."},
+		// empty values are left to the required tag
+		{"", "min=1", ""},
+		{"1", "min=one", "Invalid processor tag 'min=one' on field 'UnitsSold'."},
+		{"1", "regex=[", "Invalid processor tag 'regex=[' on field 'UnitsSold'."},
+		{"1", "size=1", "Invalid processor tag 'size=1' on field 'UnitsSold'."},
+	}
+
+	for _, test := range tests {
+		err := p.Validate(test.val, "UnitsSold", test.rule)
+		if (err == nil) != (test.err == "") || (err != nil && err.Error() != test.err) {
+			t.Fatalf("%s against %s: expected %q, got %v", test.val, test.rule, test.err, err)
+		}
+	}
+}
+
+func TestCheckTags(t *testing.T) {
+	if err := CheckTags[SalesRecord](); err != nil {
+		t.Fatalf("Expected the tags of SalesRecord to be valid, got %v", err)
+	}
+
+	type badRegex struct {
+		Code string `csv:"Code" processor:"regex=["`
+	}
+	type badLen struct {
+		Code string `csv:"Code" processor:"len=two"`
+	}
+	type badMin struct {
+		Units int64 `csv:"Units" processor:"numeric,min=one"`
+	}
+	type unknown struct {
+		Code string `csv:"Code" processor:"size=1"`
+	}
+	type mismatched struct {
+		Units int64 `csv:"Units" processor:"date"`
+	}
+
+	for name, check := range map[string]func() error{
+		"regex":      CheckTags[badRegex],
+		"len":        CheckTags[badLen],
+		"min":        CheckTags[badMin],
+		"unknown":    CheckTags[unknown],
+		"mismatched": CheckTags[mismatched],
+	} {
+		if err := check(); err == nil {
+			t.Fatalf("Expected the %s tag to be refused", name)
+		}
+	}
+
+	// the tags are checked before any row is mapped or unmarshalled
+	_, err := MapHeadersTo[badRegex]([]string{"Code"})
+	if err == nil || err.Error() != "Invalid processor tag 'regex=[' on field 'Code'." {
+		t.Fatalf("Expected the regex tag to be refused, got %v", err)
+	}
+
+	var fe *errs.FieldError
+	if _, err = Unmarshal[badLen]([]string{"ab"}); err == nil || errors.As(err, &fe) {
+		t.Fatalf("Expected the len tag to be refused rather than the value, got %v", err)
+	}
+}
+
+func TestSplitTags(t *testing.T) {
+	tags := SplitTags("numeric,required,regex=^[0-9]{1,9}$")
+	expected := []string{"numeric", "required", "regex=^[0-9]{1,9}$"}
+	if strings.Join(tags, " ") != strings.Join(expected, " ") {
+		t.Fatalf("Expected %q, got %q", expected, tags)
+	}
+
+	if tags := SplitTags(""); len(tags) != 0 {
+		t.Fatalf("Expected no tags, got %q", tags)
+	}
+}
+
 func TestUnmarshal(t *testing.T) {
 	var err error
 	var p Processor
@@ -62,6 +244,26 @@ func TestUnmarshal(t *testing.T) {
 			[]string{"Australia and Oceania", "", "Baby Food", "Offline", "H", "5/28/2010", "669165933", "6/27/2010", "9925", "255.28", "159.42", "2533654.00", "1582243.50", "951410.50"},
 			true,
 		},
+		{
+			// UnitsSold must be positive
+			[]string{"Australia and Oceania", "Tuvalu", "Baby Food", "Offline", "H", "5/28/2010", "669165933", "6/27/2010", "-9925", "255.28", "159.42", "2533654.00", "1582243.50", "951410.50"},
+			true,
+		},
+		{
+			// OrderID must be 9 digits
+			[]string{"Australia and Oceania", "Tuvalu", "Baby Food", "Offline", "H", "5/28/2010", "66916593", "6/27/2010", "9925", "255.28", "159.42", "2533654.00", "1582243.50", "951410.50"},
+			true,
+		},
+		{
+			// OrderID cannot start with a zero, lost once stored as an integer
+			[]string{"Australia and Oceania", "Tuvalu", "Baby Food", "Offline", "H", "5/28/2010", "069165933", "6/27/2010", "9925", "255.28", "159.42", "2533654.00", "1582243.50", "951410.50"},
+			true,
+		},
+		{
+			// SalesChannel is misspelled
+			[]string{"Australia and Oceania", "Tuvalu", "Baby Food", "Ofline", "H", "5/28/2010", "669165933", "6/27/2010", "9925", "255.28", "159.42", "2533654.00", "1582243.50", "951410.50"},
+			true,
+		},
 		{
 			// TotalProfit is expected to be numeric but string is passed
 			[]string{"Central America and the Caribbean", "Grenada", "Cereal", "Online", "C", "8/22/2012", "963881480", "9/15/2012", "2804", "205.70", "117.11", "576782.80", "328376.44", "SAY WHAT"},
@@ -79,8 +281,165 @@ func TestUnmarshal(t *testing.T) {
 	}
 }
 
+func TestUnmarshalTypedFields(t *testing.T) {
+	var p Processor
+
+	record := []string{"Europe", "Tuvalu", "Baby Food", "offline ", "h", "5/28/2010", "669165933", "6/27/2010", "9925", "255.28", "159.42", "2533654.00", "1582243.50", "951410.50"}
+
+	sr, err := p.Unmarshal(record, SalesRecord{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sr.SalesChannel != "Offline" || sr.OrderPriority != "H" {
+		t.Fatalf("Expected Offline & H, got %s & %s", sr.SalesChannel, sr.OrderPriority)
+	}
+
+	if sr.OrderID != 669165933 || sr.UnitsSold != 9925 {
+		t.Fatalf("Expected integers, got %d & %d", sr.OrderID, sr.UnitsSold)
+	}
+
+	if sr.ShipDate.Sub(sr.OrderDate) != 30*24*time.Hour {
+		t.Fatalf("Expected 30 days between order & shipping, got %v", sr.ShipDate.Sub(sr.OrderDate))
+	}
+
+	if !sr.TotalRevenue.Sub(sr.TotalCost).Equal(sr.TotalProfit) {
+		t.Fatalf("Expected profit of %v, got %v", sr.TotalRevenue.Sub(sr.TotalCost), sr.TotalProfit)
+	}
+}
+
+func TestUnmarshalDateLayout(t *testing.T) {
+	type shipment struct {
+		Shipped time.Time `csv:"Shipped" processor:"date=2006-01-02"`
+		Due     time.Time `csv:"Due" processor:"date=2-Jan-06"`
+	}
+
+	sh, err := Unmarshal[shipment]([]string{"2010-06-27", "5-Jul-10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sh.Shipped != time.Date(2010, 6, 27, 0, 0, 0, 0, time.UTC) || sh.Due != time.Date(2010, 7, 5, 0, 0, 0, 0, time.UTC) {
+		t.Fatalf("Unexpected dates %+v", sh)
+	}
+
+	// dates are only parsed in the given layout
+	if _, err = Unmarshal[shipment]([]string{"6/27/2010", "5-Jul-10"}); err == nil {
+		t.Fatal("Expected a date in another layout to be rejected")
+	}
+}
+
 func TestGetHeaders(t *testing.T) {
 	if len(GetHeaders()) == 0 {
 		t.Fatal("Headers should be detected")
 	}
 }
+
+func TestMapHeaders(t *testing.T) {
+	headers := GetHeaders()
+
+	// reverse the order of the headers
+	reversed := make([]string, len(headers))
+	for i, h := range headers {
+		reversed[len(headers)-1-i] = h
+	}
+
+	mapping, err := MapHeaders(reversed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row := mapping.Apply(reversed)
+	for i, h := range headers {
+		if row[i] != h {
+			t.Fatalf("\nMapping Mismatch:\nExpected: %v\nGot: %v", h, row[i])
+		}
+	}
+
+	// duplicated header in place of another
+	duplicated := append([]string{}, headers...)
+	duplicated[1] = headers[0]
+	if _, err := MapHeaders(duplicated); err == nil {
+		t.Fatal("Duplicated headers should not be mapped")
+	}
+
+	// missing header
+	if _, err := MapHeaders(headers[1:]); err == nil {
+		t.Fatal("Missing headers should not be mapped")
+	}
+
+	// unknown header
+	unknown := append([]string{}, headers...)
+	unknown[0] = "Continent"
+	if _, err := MapHeaders(unknown); err == nil {
+		t.Fatal("Unknown headers should not be mapped")
+	}
+}
+
+// returnRecord a record of a feed other than the sales records
+type returnRecord struct {
+	OrderID    string `csv:"Order ID" processor:"numeric,required"`
+	ReturnDate string `csv:"Return Date" processor:"date"`
+	Reason     string `csv:"Reason"`
+}
+
+func TestUnmarshalAnyStruct(t *testing.T) {
+	headers := Headers[returnRecord]()
+	expected := []string{"Order ID", "Return Date", "Reason"}
+	if strings.Join(headers, "|") != strings.Join(expected, "|") {
+		t.Fatalf("Expected %v, got %v", expected, headers)
+	}
+
+	mapping, err := MapHeadersTo[returnRecord]([]string{"Reason", "Order ID", "Return Date"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr, err := Unmarshal[returnRecord](mapping.Apply([]string{" Damaged ", "669165933", "6/27/2010"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rr.OrderID != "669165933" || rr.ReturnDate != "6/27/2010" || rr.Reason != "Damaged" {
+		t.Fatalf("Unexpected record %+v", rr)
+	}
+
+	_, err = Unmarshal[returnRecord]([]string{"", "6/27/2010", "Damaged"})
+	var fe *errs.FieldError
+	if !errors.As(err, &fe) || fe.Field != "OrderID" {
+		t.Fatalf("Expected OrderID to be reported, got %v", err)
+	}
+
+	// unexported fields and fields tagged csv:"-" are skipped
+	type annotated struct {
+		OrderID int64 `csv:"Order ID" processor:"numeric,required"`
+		note    string
+		Seen    bool   `csv:"-"`
+		Reason  string `csv:"Reason"`
+	}
+
+	headers = Headers[annotated]()
+	if strings.Join(headers, "|") != "Order ID|Reason" {
+		t.Fatalf("Expected Order ID|Reason, got %v", headers)
+	}
+
+	a, err := Unmarshal[annotated]([]string{"669165933", "Damaged"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a.OrderID != 669165933 || a.Reason != "Damaged" || a.note != "" || a.Seen {
+		t.Fatalf("Unexpected record %+v", a)
+	}
+
+	// only structs of strings, integers, dates & amounts
+	// can be unmarshalled
+	var ut *UnsupportedType
+	if _, err = Unmarshal[struct{ Shipped bool }]([]string{"true"}); !errors.As(err, &ut) {
+		t.Fatalf("Expected unsupported type, got %v", err)
+	}
+
+	if _, err = Unmarshal[string]([]string{"1"}); !errors.As(err, &ut) {
+		t.Fatalf("Expected unsupported type, got %v", err)
+	}
+}
@@ -3,16 +3,39 @@ package main
 import (
 	"flag"
 	"os"
+	"strings"
 
 	"github.com/dele454/medium/csv-transform-to-html/cmd"
-	"github.com/dele454/medium/csv-transform-to-html/internal/errs"
+	"github.com/dele454/medium/csv-transform-to-html/internal/checkpoint"
+	"github.com/dele454/medium/csv-transform-to-html/internal/infer"
+	"github.com/dele454/medium/csv-transform-to-html/internal/parser"
+	"github.com/dele454/medium/csv-transform-to-html/internal/rules"
+	"github.com/dele454/medium/csv-transform-to-html/internal/utils"
 )
 
 func main() {
-	var file string
+	// infer the schema of a csv file instead
+	if len(os.Args) > 1 && os.Args[1] == "infer" {
+		inferSchema(os.Args[2:])
+		return
+	}
+
+	var cfg cmd.Config
 
 	// accept arg from stdin
-	flag.StringVar(&file, "f", "", "Full path to source file for processing.")
+	flag.Var((*files)(&cfg.Files), "f", "Full path to source file for processing, or - for stdin. Gzip, bzip2 & zstd compressed files are decompressed.\nRepeat the flag, or pass paths as args, to transform several files. Glob patterns & directories are expanded.")
+	flag.StringVar(&cfg.Format, "format", "html", "Output format of the transformation: html, xml, json, ndjson, xlsx, md or txt.")
+	flag.StringVar(&cfg.TemplateDir, "templates", "", "Directory holding custom output.tmpl and/or report.tmpl templates.")
+	flag.StringVar(&cfg.OutputDir, "output", utils.DefaultOutputDir, "Directory the outputs are written to, relative to the working directory.")
+	flag.IntVar(&cfg.PageSize, "page-size", 0, "Split html output into pages of the given nos of rows plus an index page.")
+	flag.BoolVar(&cfg.Interactive, "interactive", false, "Write a self-contained html output with sorting, filtering and search.")
+	flag.BoolVar(&cfg.Summary, "summary", false, "Add totals & averages grouped by region, item type, sales channel and order priority to the html output.")
+	flag.BoolVar(&cfg.Charts, "charts", false, "Add inline svg charts of revenue by region, monthly profit and sales channel share to the html output.")
+	dialect := dialectFlags(flag.CommandLine, &cfg.Dialect)
+	flag.StringVar(&cfg.Sheet, "sheet", "", "Sheet of an xlsx source file to read. Defaults to the first sheet.")
+	flag.IntVar(&cfg.CheckpointEvery, "checkpoint", checkpoint.DefaultEvery, "Nos of rows between checkpoints of the transformation of a csv file into a single html document, 0 disables checkpoints.")
+	flag.BoolVar(&cfg.Resume, "resume", false, "Resume an interrupted transformation from its checkpoint, appending to the partially written output.")
+	tolerances := toleranceFlags(flag.CommandLine)
 	flag.Parse()
 
 	// display usage if no arg is passed
@@ -21,17 +44,130 @@ func main() {
 		return
 	}
 
-	// get file's info
-	f, err := os.Stat(file)
-	if err != nil {
+	// parse the dialect of the source file
+	if err := dialect(); err != nil {
+		panic(err)
+	}
+
+	// parse the tolerances of the business rules
+	var err error
+	if cfg.Tolerances, err = tolerances(); err != nil {
 		panic(err)
 	}
 
-	// check its a file
-	if f.IsDir() {
-		panic(errs.ErrorArgsDirSpecified)
+	// paths passed as args are transformed too
+	cfg.Files = append(cfg.Files, flag.Args()...)
+
+	// display usage if no file is passed
+	if len(cfg.Files) == 0 {
+		flag.PrintDefaults()
+		return
 	}
 
 	// kickoff the process
-	cmd.Process(file)
+	if err := cmd.Process(cfg); err != nil {
+		panic(err)
+	}
+}
+
+// inferSchema samples a csv file and prints a Go struct for its schema
+func inferSchema(args []string) {
+	var cfg cmd.InferConfig
+
+	fs := flag.NewFlagSet("infer", flag.ExitOnError)
+	fs.StringVar(&cfg.File, "f", "", "Full path to the csv file to infer the schema of, or - for stdin.")
+	fs.StringVar(&cfg.Name, "name", "SalesRecord", "Name of the struct emitted for the schema.")
+	fs.IntVar(&cfg.Sample, "sample", infer.DefaultSample, "Nos of rows sampled.")
+	dialect := dialectFlags(fs, &cfg.Dialect)
+	fs.Parse(args)
+
+	// display usage if no arg is passed
+	if len(args) == 0 {
+		fs.PrintDefaults()
+		return
+	}
+
+	// parse the dialect of the source file
+	if err := dialect(); err != nil {
+		panic(err)
+	}
+
+	if err := cmd.Infer(cfg, os.Stdout); err != nil {
+		panic(err)
+	}
+}
+
+// dialectFlags registers the flags describing the dialect of a csv
+// source file, returning a func setting the dialect once parsed.
+func dialectFlags(fs *flag.FlagSet, d *parser.Dialect) func() error {
+	var delimiter, comment, fields, encoding string
+
+	fs.StringVar(&delimiter, "delimiter", "", "Field delimiter of the source file, e.g ';' or 'tab'. Defaults to a comma.")
+	fs.StringVar(&comment, "comment", "", "Lines of the source file starting with the given character are ignored, e.g '#'.")
+	fs.BoolVar(&d.LazyQuotes, "lazy-quotes", false, "Allow quotes within unquoted fields and non-doubled quotes within quoted fields.")
+	fs.BoolVar(&d.TrimLeadingSpace, "trim-space", false, "Ignore leading white space of fields.")
+	fs.StringVar(&fields, "fields", "strict", "Fields per record policy: strict, every row must match the headers, or ragged, missing fields are left empty & extra fields are ignored.")
+	fs.BoolVar(&d.AutoDetect, "detect", false, "Detect the delimiter & comment character from the first lines of the source file, unless set explicitly.")
+	fs.StringVar(&encoding, "encoding", "auto", "Character encoding of the source file: auto, utf-8, utf-16le, utf-16be, windows-1252 or iso-8859-1.")
+
+	return func() error {
+		var err error
+
+		if d.Delimiter, err = parser.ParseDialectRune(delimiter); err != nil {
+			return err
+		}
+
+		if d.Comment, err = parser.ParseDialectRune(comment); err != nil {
+			return err
+		}
+
+		if d.FieldsPerRecord, err = parser.ParseFieldsPolicy(fields); err != nil {
+			return err
+		}
+
+		d.Encoding, err = parser.ParseEncoding(encoding)
+		return err
+	}
+}
+
+// toleranceFlags registers the flags giving the tolerances of the
+// totals of a record, returning a func reading them once parsed.
+func toleranceFlags(fs *flag.FlagSet) func() (rules.Tolerances, error) {
+	var revenue, cost, profit string
+
+	fs.StringVar(&revenue, "revenue-tolerance", rules.DefaultTolerances.Revenue.String(), "Largest difference allowed between TotalRevenue and UnitsSold × UnitPrice.")
+	fs.StringVar(&cost, "cost-tolerance", rules.DefaultTolerances.Cost.String(), "Largest difference allowed between TotalCost and UnitsSold × UnitCost.")
+	fs.StringVar(&profit, "profit-tolerance", rules.DefaultTolerances.Profit.String(), "Largest difference allowed between TotalProfit and TotalRevenue − TotalCost.")
+
+	return func() (rules.Tolerances, error) {
+		var (
+			t   rules.Tolerances
+			err error
+		)
+
+		if t.Revenue, err = rules.ParseTolerance(revenue); err != nil {
+			return t, err
+		}
+
+		if t.Cost, err = rules.ParseTolerance(cost); err != nil {
+			return t, err
+		}
+
+		t.Profit, err = rules.ParseTolerance(profit)
+		return t, err
+	}
+}
+
+// files source files passed via the repeatable -f flag
+type files []string
+
+// String lists the files
+func (f *files) String() string {
+	return strings.Join(*f, ", ")
+}
+
+// Set adds a file
+func (f *files) Set(v string) error {
+	*f = append(*f, v)
+	return nil
 }